@@ -7,6 +7,7 @@ import (
 
 	"protomorphine/tg-notes/internal/bot/handlers/help"
 	"protomorphine/tg-notes/internal/bot/handlers/notesaving"
+	"protomorphine/tg-notes/internal/bot/handlers/search"
 	"protomorphine/tg-notes/internal/bot/middleware"
 	"protomorphine/tg-notes/internal/config"
 	"protomorphine/tg-notes/internal/log"
@@ -16,21 +17,45 @@ import (
 	"github.com/go-telegram/bot/models"
 )
 
+// handlerWorkers is a count of updates handled at the same time.
+const handlerWorkers = 16
+
 type webhookRemoveFunc func()
 
-func newBot(logger *slog.Logger, cfg *config.BotConfig, defaultHandler notesaving.Handler) (*bot.Bot, error) {
+// newBot creates the bot. Handlers run with handlerCtx, so ones in progress are
+// finished on shutdown, see middleware.NewShutdownContext.
+func newBot(
+	handlerCtx context.Context,
+	logger *slog.Logger,
+	cfg *config.BotConfig,
+	userChecker middleware.UserChecker,
+	defaultHandler notesaving.Handler,
+	categoryHandler notesaving.CallbackHandler,
+	searchHandler search.Handler,
+	handlerObserver middleware.HandlerObserver,
+) (*bot.Bot, error) {
 	opts := []bot.Option{
 		bot.WithErrorsHandler(botlog.NewErrorHandler(logger)),
 		bot.WithDefaultHandler(wrapHandler(defaultHandler)),
 		bot.WithCheckInitTimeout(cfg.InitTimeout),
+		// handlers run in workers, so stopped workers mean no handlers in progress,
+		// and received updates aren't queued, so none is lost when workers stop
+		bot.WithNotAsyncHandlers(),
+		bot.WithWorkers(handlerWorkers),
+		bot.WithUpdatesChannelCap(0),
 		bot.WithMiddlewares(
+			middleware.NewShutdownContext(handlerCtx),
 			middleware.NewReqID(),
 			middleware.NewRecover(logger),
-			middleware.NewAuth(logger, cfg),
-			middleware.NewLog(logger),
+			middleware.NewAuth(logger, userChecker),
+			middleware.NewLog(logger, handlerObserver),
 		),
 	}
 
+	if cfg.ServerURL != "" {
+		opts = append(opts, bot.WithServerURL(cfg.ServerURL))
+	}
+
 	if logger.Enabled(context.Background(), slog.LevelDebug) {
 		opts = append(opts,
 			bot.WithDebug(),
@@ -45,6 +70,13 @@ func newBot(logger *slog.Logger, cfg *config.BotConfig, defaultHandler notesavin
 
 	// register additional command handlers
 	b.RegisterHandler(bot.HandlerTypeMessageText, help.Cmd, bot.MatchTypeCommand, help.New(logger))
+	b.RegisterHandler(bot.HandlerTypeMessageText, search.Cmd, bot.MatchTypeCommandStartOnly, wrapSearchHandler(searchHandler))
+	b.RegisterHandler(
+		bot.HandlerTypeCallbackQueryData,
+		notesaving.CategoryCallbackPrefix,
+		bot.MatchTypePrefix,
+		wrapCallbackHandler(categoryHandler),
+	)
 
 	return b, nil
 }
@@ -55,8 +87,21 @@ func wrapHandler(handler notesaving.Handler) bot.HandlerFunc {
 	}
 }
 
-func setWebhook(ctx context.Context, logger *slog.Logger, b *bot.Bot, webhookURL string) (webhookRemoveFunc, error) {
-	_, err := b.SetWebhook(ctx, &bot.SetWebhookParams{URL: webhookURL})
+func wrapSearchHandler(handler search.Handler) bot.HandlerFunc {
+	return func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+		handler(ctx, bot, update)
+	}
+}
+
+func wrapCallbackHandler(handler notesaving.CallbackHandler) bot.HandlerFunc {
+	return func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+		handler(ctx, bot, update)
+	}
+}
+
+// setWebhook registers webhook. Telegram sends the secret token with every update, see webhook.NewGuard.
+func setWebhook(ctx context.Context, logger *slog.Logger, b *bot.Bot, webhookURL, secret string) (webhookRemoveFunc, error) {
+	_, err := b.SetWebhook(ctx, &bot.SetWebhookParams{URL: webhookURL, SecretToken: secret})
 	if err != nil {
 		return nil, fmt.Errorf("set webhook error: %w", err)
 	}
@@ -71,3 +116,24 @@ func setWebhook(ctx context.Context, logger *slog.Logger, b *bot.Bot, webhookURL
 		logger.Info("webhook was deleted")
 	}, nil
 }
+
+// startPolling starts receiving updates via long polling. Blocks until ctx is done
+// and handlers in progress are finished.
+func startPolling(ctx context.Context, logger *slog.Logger, b *bot.Bot, ready *readiness) error {
+	// getUpdates doesn't work while an outgoing webhook is set up
+	if _, err := b.DeleteWebhook(ctx, &bot.DeleteWebhookParams{}); err != nil {
+		return fmt.Errorf("delete webhook error: %w", err)
+	}
+
+	logger.Info("starting long polling")
+	ready.set(checkUpdates, true)
+
+	// updates aren't taken since ctx is done, while Start waits for handlers in progress
+	stop := context.AfterFunc(ctx, func() { ready.set(checkUpdates, false) })
+	defer stop()
+
+	b.Start(ctx)
+
+	logger.Info("long polling stopped")
+	return nil
+}
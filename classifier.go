@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+)
+
+// newClassifier loads persisted classifier model if it was trained on the current
+// version of notes in the storage. Otherwise it trains a new model on given notes and persists it.
+func newClassifier(
+	logger *slog.Logger,
+	cfg *config.ClassifierConfig,
+	storage storage.Storage,
+	processor *nlp.Processor,
+	notes []domain.Note,
+) *nlp.Classifier {
+	if cfg.ModelPath == "" {
+		return nlp.NewClassifier(processor, notes)
+	}
+
+	logger = logger.With(slog.String("path", cfg.ModelPath))
+
+	head, err := storage.Version()
+	if err != nil {
+		logger.Warn("can't get storage version, model won't be persisted", log.Err(err))
+		return nlp.NewClassifier(processor, notes)
+	}
+
+	classifier, err := nlp.LoadClassifierFile(cfg.ModelPath, processor, head)
+	switch {
+	case err == nil:
+		logger.Info("classifier model loaded")
+		return classifier
+
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, nlp.ErrModelOutdated):
+		logger.Info("classifier model needs training", slog.String("reason", err.Error()))
+
+	default:
+		logger.Warn("error while loading classifier model", log.Err(err))
+	}
+
+	classifier = nlp.NewClassifier(processor, notes)
+
+	if err := classifier.SaveFile(cfg.ModelPath, head); err != nil {
+		logger.Warn("error while saving classifier model", log.Err(err))
+	} else {
+		logger.Info("classifier model saved")
+	}
+
+	return classifier
+}
+
+// saveClassifiers persists classifier models of users' storages, e.g. on shutdown, so notes
+// learned since the start aren't lost. Models are keyed by the current version of notes,
+// so they must not change anymore. A model isn't saved, if the storage has changes
+// not saved to the remote yet, since they may be missing in the version after restart.
+func saveClassifiers(storages []userStorage) {
+	for _, s := range storages {
+		if s.modelPath == "" {
+			continue
+		}
+
+		logger := s.logger.With(slog.String("path", s.modelPath))
+
+		if pending, ok := s.storage.(pendingStorage); ok && pending.Pending() > 0 {
+			logger.Warn("storage has pending changes, classifier model isn't saved")
+			continue
+		}
+
+		version, err := s.storage.Version()
+		if err != nil {
+			logger.Warn("can't get storage version, classifier model isn't saved", log.Err(err))
+			continue
+		}
+
+		if err := s.classifier.SaveFile(s.modelPath, version); err != nil {
+			logger.Warn("error while saving classifier model", log.Err(err))
+			continue
+		}
+
+		logger.Info("classifier model saved")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/fs"
+
+	"github.com/stretchr/testify/require"
+)
+
+// unsavedStorage is a storage with changes, which aren't saved to the remote.
+type unsavedStorage struct {
+	storage.Storage
+}
+
+func (unsavedStorage) Pending() int {
+	return 1
+}
+
+func TestSaveClassifiers(t *testing.T) {
+	processor, err := nlp.NewProcessor()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		wrap          func(storage.Storage) storage.Storage
+		expectedSaved bool
+	}{
+		{
+			name:          "saved storage",
+			wrap:          func(s storage.Storage) storage.Storage { return s },
+			expectedSaved: true,
+		},
+		{
+			name:          "storage with pending changes",
+			wrap:          func(s storage.Storage) storage.Storage { return unsavedStorage{Storage: s} },
+			expectedSaved: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			notes, err := fs.New(&config.StorageConfig{Path: t.TempDir(), CollisionPolicy: config.CollisionSuffix})
+			require.NoError(t, err)
+
+			classifier := nlp.NewClassifier(processor, nil)
+
+			// the note is learned after the start, so the model differs from the one saved at startup
+			note, _, err := notes.Add(context.Background(), domain.Note{Name: "note", Category: "dev", Content: "golang generics"})
+			require.NoError(t, err)
+			classifier.Learn(note)
+
+			modelPath := filepath.Join(t.TempDir(), "model.json")
+
+			saveClassifiers([]userStorage{{
+				logger:     slog.New(log.NewDiscardHandler()),
+				storage:    tc.wrap(notes),
+				classifier: classifier,
+				modelPath:  modelPath,
+			}})
+
+			version, err := notes.Version()
+			require.NoError(t, err)
+
+			loaded, err := nlp.LoadClassifierFile(modelPath, processor, version)
+			if !tc.expectedSaved {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			_, category := loaded.Classify("golang")
+			require.Equal(t, domain.Category("dev"), category)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+)
+
+// evalCmd is the CLI subcommand to evaluate classifier on notes from the repository.
+const evalCmd = "eval"
+
+// evaluation report formats
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type evalArgs struct {
+	configPath string
+	userID     int64
+	folds      int
+	seed       uint64
+	format     string
+}
+
+// runEval runs k-fold cross-validation of the classifier and prints report to stdout.
+// Returns process exit code.
+func runEval(args []string) int {
+	evalArgs, err := parseEvalArgs(args)
+	if err != nil {
+		slog.Error("error while parsing CLI args", log.Err(err))
+		return 1
+	}
+
+	cfg, err := config.Load(evalArgs.configPath)
+	if err != nil {
+		slog.Error("error while loading config", log.Err(err))
+		return 1
+	}
+
+	userCfg := &cfg.Users[0]
+	if evalArgs.userID != 0 {
+		var ok bool
+		if userCfg, ok = cfg.User(evalArgs.userID); !ok {
+			slog.Error("user not found in config", slog.Int64("userID", evalArgs.userID))
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	storage, err := newStorage(userCfg)
+	if err != nil {
+		slog.Error("error while setting up storage", log.Err(err))
+		return 1
+	}
+
+	notes, err := storage.Notes(ctx)
+	if err != nil {
+		slog.Error("error while getting notes", log.Err(err))
+		return 1
+	}
+
+	processor, err := nlp.NewProcessor()
+	if err != nil {
+		slog.Error("error while creating NLP processor", log.Err(err))
+		return 1
+	}
+
+	report, err := nlp.CrossValidate(processor, notes, evalArgs.folds, evalArgs.seed)
+	if err != nil {
+		slog.Error("error while evaluating classifier", log.Err(err))
+		return 1
+	}
+
+	if evalArgs.format == formatJSON {
+		err = writeJSONReport(os.Stdout, report)
+	} else {
+		err = writeTextReport(os.Stdout, report)
+	}
+
+	if err != nil {
+		slog.Error("error while writing report", log.Err(err))
+		return 1
+	}
+
+	return 0
+}
+
+func parseEvalArgs(args []string) (*evalArgs, error) {
+	flags := flag.NewFlagSet(evalCmd, flag.ContinueOnError)
+
+	configPath := flags.String("config", "", "path to config file")
+	userID := flags.Int64("user", 0, "ID of the user whose notes are evaluated, the first configured user by default")
+	folds := flags.Int("folds", 5, "count of cross-validation folds")
+	seed := flags.Uint64("seed", 1, "seed to shuffle notes before splitting into folds")
+	format := flags.String("format", formatText, "report format: text or json")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := validateConfigPath(*configPath); err != nil {
+		return nil, err
+	}
+
+	if *format != formatText && *format != formatJSON {
+		return nil, fmt.Errorf("unknown report format: %s", *format)
+	}
+
+	return &evalArgs{
+		configPath: *configPath,
+		userID:     *userID,
+		folds:      *folds,
+		seed:       *seed,
+		format:     *format,
+	}, nil
+}
+
+func writeJSONReport(w io.Writer, report *nlp.Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func writeTextReport(w io.Writer, report *nlp.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "notes: %d, folds: %d, accuracy: %.3f\n\n", report.Notes, report.Folds, report.Accuracy)
+
+	fmt.Fprintln(tw, "CATEGORY\tPRECISION\tRECALL\tF1\tSUPPORT")
+	for _, m := range report.Categories {
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%d\n", m.Category, m.Precision, m.Recall, m.F1, m.Support)
+	}
+
+	categories := make([]domain.Category, 0, len(report.Categories))
+	for _, m := range report.Categories {
+		categories = append(categories, m.Category)
+	}
+
+	// classifier may predict category, which is absent in the test folds
+	for _, predicted := range report.Confusion {
+		for category := range predicted {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	slices.Sort(categories)
+
+	fmt.Fprintln(tw, "\nCONFUSION MATRIX (rows: actual, columns: predicted)")
+
+	header := make([]string, 0, len(categories)+1)
+	header = append(header, "")
+	for _, category := range categories {
+		header = append(header, string(category))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, actual := range categories {
+		row := make([]string, 0, len(categories)+1)
+		row = append(row, string(actual))
+		for _, predicted := range categories {
+			row = append(row, fmt.Sprint(report.Confusion[actual][predicted]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	fmt.Fprintln(tw, "\nTHRESHOLD\tCOVERAGE\tACCURACY")
+	for _, p := range report.Thresholds {
+		fmt.Fprintf(tw, "%.2f\t%.3f\t%.3f\n", p.Threshold, p.Coverage, p.Accuracy)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,269 @@
+// Package api provides HTTP API to save and list notes besides the bot,
+// e.g. from scripts and browser extensions.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+)
+
+// maxBodySize is a maximum size of request body.
+const maxBodySize = 1 << 20
+
+// Notes is an interface of notes usecases of the user from request context.
+//
+//mockery:generate: true
+type Notes interface {
+	Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error)
+	Notes(ctx context.Context) ([]domain.Note, error)
+	Categories(ctx context.Context) ([]domain.Category, error)
+	Sync(ctx context.Context) error
+}
+
+// SaveRequest is a body of the request to save a new note.
+type SaveRequest struct {
+	Text string   `json:"text"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// SaveResponse is a body of the response with the saved note.
+type SaveResponse struct {
+	Title      string   `json:"title"`
+	Name       string   `json:"name"`
+	Path       string   `json:"path"`
+	Category   string   `json:"category"`
+	Outcome    string   `json:"outcome"`
+	Fallback   bool     `json:"fallback"` // category is the default one, since the prediction isn't confident enough
+	Candidates []string `json:"candidates"`
+}
+
+// Note is a stored note in the response.
+type Note struct {
+	Title    string    `json:"title"`
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Category string    `json:"category"`
+	Content  string    `json:"content"`
+	Created  time.Time `json:"created,omitzero"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+// ErrorResponse is a body of the response with an error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ConflictResponse is a body of the response, when pending changes conflict with remote ones
+// and are saved aside to the locations, e.g. a side branch, to be merged manually.
+type ConflictResponse struct {
+	Error     string   `json:"error"`
+	Locations []string `json:"locations"`
+}
+
+// New creates handler of the API. Requests are authorized with bearer tokens, which map
+// to IDs of the users, and are served with the notes usecases of the authorized user.
+func New(logger *slog.Logger, notes Notes, tokens map[string]int64) http.Handler {
+	logger = logger.With(slog.String("component", "api"))
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/notes", saveNote(logger, notes))
+	mux.HandleFunc("GET /api/notes", listNotes(logger, notes))
+	mux.HandleFunc("GET /api/categories", listCategories(logger, notes))
+	mux.HandleFunc("POST /api/sync", syncNotes(logger, notes))
+
+	return auth(logger, tokens, mux)
+}
+
+// auth authorizes requests by bearer token. ID of authorized user is stored in request context, see users.ID.
+func auth(logger *slog.Logger, tokens map[string]int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			if id, found := userByToken(tokens, token); found {
+				next.ServeHTTP(w, r.WithContext(users.WithID(r.Context(), id)))
+				return
+			}
+		}
+
+		logger.Warn("unauthorized API request", slog.String("path", r.URL.Path))
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+	})
+}
+
+// userByToken returns ID of the user with given token. Tokens are compared in constant time.
+func userByToken(tokens map[string]int64, token string) (int64, bool) {
+	if token == "" {
+		return 0, false
+	}
+
+	for t, id := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+func saveNote(logger *slog.Logger, notes Notes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "api.saveNote"
+		logger := logger.With(log.Op(op))
+
+		var req SaveRequest
+
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		if strings.TrimSpace(req.Text) == "" {
+			writeError(w, http.StatusBadRequest, "note text is required")
+			return
+		}
+
+		res, err := notes.Save(r.Context(), models.NoteInput{
+			Text: req.Text,
+			Meta: domain.Metadata{Tags: req.Tags},
+		})
+		if err != nil {
+			logger.Error("error while saving note", log.Err(err))
+			writeError(w, http.StatusInternalServerError, "error while saving note")
+			return
+		}
+
+		logger.Info("note saved", slog.String("path", res.Path), slog.String("outcome", string(res.Outcome)))
+
+		candidates := make([]string, 0, len(res.Candidates))
+		for _, category := range res.Candidates {
+			candidates = append(candidates, string(category))
+		}
+
+		writeJSON(w, http.StatusCreated, SaveResponse{
+			Title:      res.Title,
+			Name:       res.Name,
+			Path:       res.Path,
+			Category:   string(res.Category),
+			Outcome:    string(res.Outcome),
+			Fallback:   res.Fallback,
+			Candidates: candidates,
+		})
+	}
+}
+
+// listNotes returns stored notes, filtered by category, if it's given in the query.
+func listNotes(logger *slog.Logger, notes Notes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "api.listNotes"
+		logger := logger.With(log.Op(op))
+
+		stored, err := notes.Notes(r.Context())
+		if err != nil {
+			logger.Error("error while getting notes", log.Err(err))
+			writeError(w, http.StatusInternalServerError, "error while getting notes")
+			return
+		}
+
+		category := domain.Category(r.URL.Query().Get("category"))
+
+		res := make([]Note, 0, len(stored))
+		for _, note := range stored {
+			if category != "" && note.Category != category {
+				continue
+			}
+
+			res = append(res, Note{
+				Title:    note.Title,
+				Name:     note.Name,
+				Path:     domain.NotePath(note.Category, note.Name),
+				Category: string(note.Category),
+				Content:  note.Content,
+				Created:  note.Meta.Created,
+				Tags:     note.Meta.Tags,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func listCategories(logger *slog.Logger, notes Notes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "api.listCategories"
+		logger := logger.With(log.Op(op))
+
+		categories, err := notes.Categories(r.Context())
+		if err != nil {
+			logger.Error("error while getting categories", log.Err(err))
+			writeError(w, http.StatusInternalServerError, "error while getting categories")
+			return
+		}
+
+		res := make([]string, 0, len(categories))
+		for _, category := range categories {
+			res = append(res, string(category))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// syncNotes saves pending changes to the remote storage right away. Conflicting changes
+// are reported with locations, which they are saved to.
+func syncNotes(logger *slog.Logger, notes Notes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "api.syncNotes"
+		logger := logger.With(log.Op(op))
+
+		err := notes.Sync(r.Context())
+
+		var conflict *storage.ConflictError
+		if errors.As(err, &conflict) {
+			logger.Warn("pending changes conflict with remote ones, saved aside",
+				slog.Any("locations", conflict.Locations), log.Err(err))
+
+			msg := "pending changes conflict with remote ones, they are saved aside to be merged manually"
+			if conflict.Err != nil {
+				msg += ", other changes are kept until the next update"
+			}
+
+			writeJSON(w, http.StatusConflict, ConflictResponse{Error: msg, Locations: conflict.Locations})
+			return
+		}
+
+		if err != nil {
+			logger.Error("error while saving pending changes", log.Err(err))
+			writeError(w, http.StatusBadGateway, "error while saving pending changes, they are kept until the next update")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// the response is already started, so the error can't be reported to the client
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg})
+}
@@ -0,0 +1,218 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"protomorphine/tg-notes/internal/api"
+	"protomorphine/tg-notes/internal/api/mocks"
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "secret-token"
+
+// userID matches context of the request authorized with the token.
+func userID(id int64) any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		got, ok := users.ID(ctx)
+		return ok && got == id
+	})
+}
+
+func TestAPI(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		token          string
+		setupNotes     func(*mocks.Notes)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "missing token",
+			method:         http.MethodGet,
+			target:         "/api/categories",
+			setupNotes:     func(*mocks.Notes) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"invalid or missing bearer token"}`,
+		},
+		{
+			name:           "invalid token",
+			method:         http.MethodGet,
+			target:         "/api/categories",
+			token:          "wrong",
+			setupNotes:     func(*mocks.Notes) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"invalid or missing bearer token"}`,
+		},
+		{
+			name:   "save note",
+			method: http.MethodPost,
+			target: "/api/notes",
+			body:   `{"text":"generics in go","tags":["go"]}`,
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				input := models.NoteInput{Text: "generics in go", Meta: domain.Metadata{Tags: []string{"go"}}}
+
+				m.EXPECT().Save(userID(42), input).Return(models.SaveResult{
+					Title:      "generics in go",
+					Name:       "generics-in-go",
+					Path:       "dev/generics-in-go.md",
+					Outcome:    domain.SaveCreated,
+					Category:   "dev",
+					Candidates: []domain.Category{"dev", "go"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{"title":"generics in go","name":"generics-in-go","path":"dev/generics-in-go.md",` +
+				`"category":"dev","outcome":"created","fallback":false,"candidates":["dev","go"]}`,
+		},
+		{
+			name:           "save empty note",
+			method:         http.MethodPost,
+			target:         "/api/notes",
+			body:           `{"text":"  "}`,
+			token:          token,
+			setupNotes:     func(*mocks.Notes) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"note text is required"}`,
+		},
+		{
+			name:           "save invalid body",
+			method:         http.MethodPost,
+			target:         "/api/notes",
+			body:           `text`,
+			token:          token,
+			setupNotes:     func(*mocks.Notes) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid request body"}`,
+		},
+		{
+			name:   "save error",
+			method: http.MethodPost,
+			target: "/api/notes",
+			body:   `{"text":"text"}`,
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				m.EXPECT().Save(mock.Anything, mock.Anything).Return(models.SaveResult{}, errors.New("disk is full")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"error while saving note"}`,
+		},
+		{
+			name:   "list notes of category",
+			method: http.MethodGet,
+			target: "/api/notes?category=dev",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				m.EXPECT().Notes(userID(42)).Return([]domain.Note{
+					{Title: "generics", Name: "generics", Category: "dev", Content: "type params"},
+					{Title: "bread", Name: "bread", Category: "food", Content: "flour"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"title":"generics","name":"generics","path":"dev/generics.md","category":"dev","content":"type params"}]`,
+		},
+		{
+			name:   "list categories",
+			method: http.MethodGet,
+			target: "/api/categories",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				m.EXPECT().Categories(userID(42)).Return([]domain.Category{"dev", "food"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `["dev","food"]`,
+		},
+		{
+			name:   "sync",
+			method: http.MethodPost,
+			target: "/api/sync",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				m.EXPECT().Sync(userID(42)).Return(nil).Once()
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "sync error",
+			method: http.MethodPost,
+			target: "/api/sync",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				m.EXPECT().Sync(mock.Anything).Return(errors.New("remote is unavailable")).Once()
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"error":"error while saving pending changes, they are kept until the next update"}`,
+		},
+		{
+			name:   "sync conflict",
+			method: http.MethodPost,
+			target: "/api/sync",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				err := fmt.Errorf("sync: %w", &storage.ConflictError{Locations: []string{"main-conflict-20250301-103000"}})
+				m.EXPECT().Sync(mock.Anything).Return(err).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody: `{"error":"pending changes conflict with remote ones, they are saved aside to be merged manually",` +
+				`"locations":["main-conflict-20250301-103000"]}`,
+		},
+		{
+			name:   "sync conflict and error",
+			method: http.MethodPost,
+			target: "/api/sync",
+			token:  token,
+			setupNotes: func(m *mocks.Notes) {
+				err := &storage.ConflictError{Locations: []string{"go/note.conflict-20250301-103000.md"}, Err: errors.New("server is unavailable")}
+				m.EXPECT().Sync(mock.Anything).Return(err).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody: `{"error":"pending changes conflict with remote ones, they are saved aside to be merged manually, ` +
+				`other changes are kept until the next update","locations":["go/note.conflict-20250301-103000.md"]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			notes := mocks.NewNotes(t)
+			tc.setupNotes(notes)
+
+			handler := api.New(slog.New(log.NewDiscardHandler()), notes, map[string]int64{token: 42})
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatus, rec.Code)
+
+			if tc.expectedBody == "" {
+				require.Empty(t, rec.Body.String())
+				return
+			}
+
+			require.JSONEq(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
@@ -3,7 +3,25 @@ package models
 
 import "protomorphine/tg-notes/internal/domain"
 
+// NoteInput represents a new note to save.
+type NoteInput struct {
+	Text        string
+	Attachments []domain.Attachment
+	Meta        domain.Metadata // source of the note, creation time and score are set on save
+}
+
 type SaveResult struct {
+	Title      string
+	Name       string             // note file name, which identifies the note in category
+	Path       string             // note file path in the storage
+	Outcome    domain.SaveOutcome // whether note was saved under requested name, renamed or appended
+	Category   domain.Category
+	Fallback   bool              // category is the default one, since the prediction isn't confident enough
+	Candidates []domain.Category // the most probable categories, ordered by probability
+}
+
+type SearchResult struct {
 	Title    string
 	Category domain.Category
+	Snippet  string
 }
@@ -2,13 +2,20 @@ package nlp
 
 import (
 	"math"
+	"sync"
 
 	"protomorphine/tg-notes/internal/domain"
 )
 
 // Classifier implements a Multinomial Naive Bayes classifier for text documents.
+// It can be trained incrementally and is safe for concurrent use.
 type Classifier struct {
-	nlpProcessor   *Processor
+	nlpProcessor *Processor
+
+	mu             sync.RWMutex
+	totalDocs      int
+	docsInCat      map[domain.Category]int
+	vocab          map[string]int // token occurrences over all categories
 	vocabSize      int
 	wordCountByCat map[domain.Category]int
 	catProbs       map[domain.Category]float64
@@ -17,44 +24,124 @@ type Classifier struct {
 
 // NewClassifier creates a new Classifier.
 func NewClassifier(processor *Processor, dataset []domain.Note) *Classifier {
-	c := &Classifier{
-		wordCountByCat: make(map[domain.Category]int),
-		freqByCat:      make(map[domain.Category]map[string]int),
-		catProbs:       make(map[domain.Category]float64),
-		nlpProcessor:   processor,
-	}
+	c := &Classifier{nlpProcessor: processor}
 
 	c.train(dataset)
 	return c
 }
 
-// train fits internal values with given dataset.
+// Retrain replaces the model with one trained on given dataset,
+// e.g. when notes were changed outside of the bot.
+func (c *Classifier) Retrain(dataset []domain.Note) {
+	c.train(dataset)
+}
+
+// train fits internal values with given dataset from scratch.
 func (c *Classifier) train(dataset []domain.Note) {
-	vocab := make(map[string]struct{})
-	docsInCat := make(map[domain.Category]int)
-	totalDocs := len(dataset)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.totalDocs = 0
+	c.docsInCat = make(map[domain.Category]int)
+	c.vocab = make(map[string]int)
+	c.vocabSize = 0
+	c.wordCountByCat = make(map[domain.Category]int)
+	c.freqByCat = make(map[domain.Category]map[string]int)
+	c.catProbs = make(map[domain.Category]float64)
 
 	for _, note := range dataset {
-		category := note.Category
-		if _, ok := c.freqByCat[category]; !ok {
-			c.freqByCat[category] = make(map[string]int)
+		c.learn(note.Category, c.nlpProcessor.Process(note.Content))
+	}
+
+	c.updateCatProbs()
+}
+
+// Learn updates the model with a new note.
+func (c *Classifier) Learn(note domain.Note) {
+	tokens := c.nlpProcessor.Process(note.Content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.learn(note.Category, tokens)
+	c.updateCatProbs()
+}
+
+// Forget removes a previously learned note from the model,
+// e.g. when the note was moved to another category.
+func (c *Classifier) Forget(note domain.Note) {
+	tokens := c.nlpProcessor.Process(note.Content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.forget(note.Category, tokens)
+	c.updateCatProbs()
+}
+
+// learn adds document tokens to category counters. Caller must hold the write lock.
+func (c *Classifier) learn(category domain.Category, tokens []string) {
+	if _, ok := c.freqByCat[category]; !ok {
+		c.freqByCat[category] = make(map[string]int)
+	}
+
+	c.totalDocs++
+	c.docsInCat[category]++
+
+	for _, token := range tokens {
+		c.vocab[token]++
+		c.wordCountByCat[category]++
+		c.freqByCat[category][token]++
+	}
+
+	c.vocabSize = len(c.vocab)
+}
+
+// forget subtracts document tokens from category counters. Caller must hold the write lock.
+func (c *Classifier) forget(category domain.Category, tokens []string) {
+	if c.docsInCat[category] == 0 {
+		return
+	}
+
+	c.totalDocs--
+	c.docsInCat[category]--
+
+	freqs := c.freqByCat[category]
+
+	for _, token := range tokens {
+		// note content may differ from the learned one, don't go below zero
+		if freqs[token] == 0 {
+			continue
 		}
 
-		docsInCat[category]++
+		freqs[token]--
+		if freqs[token] == 0 {
+			delete(freqs, token)
+		}
 
-		tokens := c.nlpProcessor.Process(note.Content)
-		for _, token := range tokens {
-			vocab[token] = struct{}{}
-			c.wordCountByCat[category]++
-			c.freqByCat[category][token]++
+		c.wordCountByCat[category]--
+
+		c.vocab[token]--
+		if c.vocab[token] == 0 {
+			delete(c.vocab, token)
 		}
 	}
 
-	for category, count := range docsInCat {
-		c.catProbs[category] = math.Log(float64(count) / float64(totalDocs))
+	if c.docsInCat[category] == 0 {
+		delete(c.docsInCat, category)
+		delete(c.wordCountByCat, category)
+		delete(c.freqByCat, category)
+		delete(c.catProbs, category)
 	}
 
-	c.vocabSize = len(vocab)
+	c.vocabSize = len(c.vocab)
+}
+
+// updateCatProbs recalculates category priors. Caller must hold the write lock.
+func (c *Classifier) updateCatProbs() {
+	for category, count := range c.docsInCat {
+		c.catProbs[category] = math.Log(float64(count) / float64(c.totalDocs))
+	}
 }
 
 // Classify returns map with category probabilities for given text.
@@ -62,6 +149,9 @@ func (c *Classifier) Classify(text string) (map[domain.Category]float64, domain.
 	logPredictions := make(map[domain.Category]float64)
 	tokens := c.nlpProcessor.Process(text)
 
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	for category, freqs := range c.freqByCat {
 		logProb := c.catProbs[category]
 
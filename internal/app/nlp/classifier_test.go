@@ -0,0 +1,57 @@
+package nlp_test
+
+import (
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLearnAndForget(t *testing.T) {
+	processor, err := nlp.NewProcessor()
+	require.NoError(t, err)
+
+	classifier := nlp.NewClassifier(processor, []domain.Note{
+		{Category: "dev", Content: "golang compiler generics interface"},
+		{Category: "food", Content: "pasta tomato recipe cheese"},
+	})
+
+	_, category := classifier.Classify("guitar chords song")
+	require.NotEqual(t, domain.Category("music"), category)
+
+	note := domain.Note{Category: "music", Content: "guitar chords song melody"}
+
+	classifier.Learn(note)
+
+	probs, category := classifier.Classify("guitar chords song")
+	require.Equal(t, domain.Category("music"), category)
+	require.Len(t, probs, 3)
+
+	classifier.Forget(note)
+
+	probs, category = classifier.Classify("golang generics")
+	require.Equal(t, domain.Category("dev"), category)
+	require.Len(t, probs, 2)
+}
+
+func TestRetrain(t *testing.T) {
+	processor, err := nlp.NewProcessor()
+	require.NoError(t, err)
+
+	classifier := nlp.NewClassifier(processor, []domain.Note{
+		{Category: "dev", Content: "golang compiler generics interface"},
+		{Category: "food", Content: "pasta tomato recipe cheese"},
+	})
+
+	classifier.Retrain([]domain.Note{
+		{Category: "music", Content: "guitar chords song melody"},
+		{Category: "food", Content: "pasta tomato recipe cheese"},
+	})
+
+	probs, category := classifier.Classify("guitar chords song")
+	require.Equal(t, domain.Category("music"), category)
+	require.Len(t, probs, 2)
+	require.NotContains(t, probs, domain.Category("dev"))
+}
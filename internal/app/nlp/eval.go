@@ -0,0 +1,190 @@
+package nlp
+
+import (
+	"cmp"
+	"errors"
+	"math/rand/v2"
+	"slices"
+
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// thresholdStep is a step between thresholds in evaluation report.
+const thresholdStep = .05
+
+// CategoryMetrics represents classification quality for a single category.
+type CategoryMetrics struct {
+	Category  domain.Category `json:"category"`
+	Precision float64         `json:"precision"`
+	Recall    float64         `json:"recall"`
+	F1        float64         `json:"f1"`
+	Support   int             `json:"support"` // count of notes in category
+}
+
+// ThresholdPoint shows how many predictions pass given threshold and how accurate they are.
+type ThresholdPoint struct {
+	Threshold float64 `json:"threshold"`
+	Coverage  float64 `json:"coverage"` // share of notes with top probability not less than threshold
+	Accuracy  float64 `json:"accuracy"` // share of correct predictions among covered notes
+}
+
+// Report represents results of classifier cross-validation.
+type Report struct {
+	Folds      int                                         `json:"folds"`
+	Notes      int                                         `json:"notes"`
+	Accuracy   float64                                     `json:"accuracy"`
+	Categories []CategoryMetrics                           `json:"categories"`
+	Confusion  map[domain.Category]map[domain.Category]int `json:"confusion"` // actual category -> predicted category -> count
+	Thresholds []ThresholdPoint                            `json:"thresholds"`
+}
+
+type prediction struct {
+	actual    domain.Category
+	predicted domain.Category
+	prob      float64
+}
+
+// CrossValidate evaluates classifier on given dataset with k-fold cross-validation.
+// Dataset is shuffled with given seed before splitting into folds.
+func CrossValidate(processor *Processor, dataset []domain.Note, folds int, seed uint64) (*Report, error) {
+	if folds < 2 {
+		return nil, errors.New("at least 2 folds are required")
+	}
+
+	if len(dataset) < folds {
+		return nil, errors.New("dataset is smaller than folds count")
+	}
+
+	notes := slices.Clone(dataset)
+
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	rnd.Shuffle(len(notes), func(i, j int) {
+		notes[i], notes[j] = notes[j], notes[i]
+	})
+
+	predictions := make([]prediction, 0, len(notes))
+
+	for fold := range folds {
+		var train, test []domain.Note
+
+		for i, note := range notes {
+			if i%folds == fold {
+				test = append(test, note)
+			} else {
+				train = append(train, note)
+			}
+		}
+
+		classifier := NewClassifier(processor, train)
+
+		for _, note := range test {
+			probs, category := classifier.Classify(note.Content)
+			predictions = append(predictions, prediction{
+				actual:    note.Category,
+				predicted: category,
+				prob:      probs[category],
+			})
+		}
+	}
+
+	report := &Report{
+		Folds:      folds,
+		Notes:      len(notes),
+		Confusion:  make(map[domain.Category]map[domain.Category]int),
+		Categories: categoryMetrics(predictions),
+		Thresholds: thresholdCurve(predictions),
+	}
+
+	correct := 0
+	for _, p := range predictions {
+		if p.actual == p.predicted {
+			correct++
+		}
+
+		if _, ok := report.Confusion[p.actual]; !ok {
+			report.Confusion[p.actual] = make(map[domain.Category]int)
+		}
+		report.Confusion[p.actual][p.predicted]++
+	}
+
+	report.Accuracy = ratio(correct, len(predictions))
+
+	return report, nil
+}
+
+func categoryMetrics(predictions []prediction) []CategoryMetrics {
+	truePositive := make(map[domain.Category]int)
+	predicted := make(map[domain.Category]int)
+	actual := make(map[domain.Category]int)
+
+	for _, p := range predictions {
+		actual[p.actual]++
+		predicted[p.predicted]++
+
+		if p.actual == p.predicted {
+			truePositive[p.actual]++
+		}
+	}
+
+	metrics := make([]CategoryMetrics, 0, len(actual))
+
+	for category, support := range actual {
+		precision := ratio(truePositive[category], predicted[category])
+		recall := ratio(truePositive[category], support)
+
+		var f1 float64
+		if precision+recall > 0 {
+			f1 = 2 * precision * recall / (precision + recall)
+		}
+
+		metrics = append(metrics, CategoryMetrics{
+			Category:  category,
+			Precision: precision,
+			Recall:    recall,
+			F1:        f1,
+			Support:   support,
+		})
+	}
+
+	slices.SortFunc(metrics, func(a, b CategoryMetrics) int {
+		return cmp.Compare(a.Category, b.Category)
+	})
+
+	return metrics
+}
+
+func thresholdCurve(predictions []prediction) []ThresholdPoint {
+	var points []ThresholdPoint
+
+	for i := 0; float64(i)*thresholdStep < 1; i++ {
+		threshold := float64(i) * thresholdStep
+
+		covered, correct := 0, 0
+		for _, p := range predictions {
+			if p.prob < threshold {
+				continue
+			}
+
+			covered++
+			if p.actual == p.predicted {
+				correct++
+			}
+		}
+
+		points = append(points, ThresholdPoint{
+			Threshold: threshold,
+			Coverage:  ratio(covered, len(predictions)),
+			Accuracy:  ratio(correct, covered),
+		})
+	}
+
+	return points
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b)
+}
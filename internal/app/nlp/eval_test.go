@@ -0,0 +1,49 @@
+package nlp_test
+
+import (
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrossValidate(t *testing.T) {
+	processor, err := nlp.NewProcessor()
+	require.NoError(t, err)
+
+	var notes []domain.Note
+	for range 4 {
+		notes = append(notes,
+			domain.Note{Category: "dev", Content: "golang compiler generics interface"},
+			domain.Note{Category: "food", Content: "pasta tomato recipe cheese"},
+		)
+	}
+
+	t.Run("report", func(t *testing.T) {
+		report, err := nlp.CrossValidate(processor, notes, 2, 1)
+		require.NoError(t, err)
+
+		require.Equal(t, len(notes), report.Notes)
+		require.InDelta(t, 1, report.Accuracy, 1e-9)
+		require.Equal(t, map[domain.Category]map[domain.Category]int{
+			"dev":  {"dev": 4},
+			"food": {"food": 4},
+		}, report.Confusion)
+
+		require.Len(t, report.Categories, 2)
+		for _, m := range report.Categories {
+			require.InDelta(t, 1, m.F1, 1e-9)
+			require.Equal(t, 4, m.Support)
+		}
+
+		require.NotEmpty(t, report.Thresholds)
+		require.InDelta(t, 1, report.Thresholds[0].Coverage, 1e-9)
+	})
+
+	t.Run("too many folds", func(t *testing.T) {
+		_, err := nlp.CrossValidate(processor, notes, len(notes)+1, 1)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,129 @@
+package nlp
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// modelFormatVersion must be incremented on every change of the model struct.
+const modelFormatVersion = 1
+
+// ErrModelOutdated is returned when persisted model can't be used with
+// current processor or was trained on another dataset.
+var ErrModelOutdated = errors.New("model is outdated")
+
+// model represents persisted classifier state.
+type model struct {
+	FormatVersion    int
+	ProcessorVersion string
+	Fingerprint      string // identifies training dataset, e.g. HEAD commit hash
+
+	TotalDocs      int
+	DocsInCat      map[domain.Category]int
+	Vocab          map[string]int
+	WordCountByCat map[domain.Category]int
+	FreqByCat      map[domain.Category]map[string]int
+}
+
+// Save writes classifier state to w. The fingerprint identifies the dataset
+// classifier was trained on and is checked by LoadClassifier.
+func (c *Classifier) Save(w io.Writer, fingerprint string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	m := model{
+		FormatVersion:    modelFormatVersion,
+		ProcessorVersion: c.nlpProcessor.Version(),
+		Fingerprint:      fingerprint,
+		TotalDocs:        c.totalDocs,
+		DocsInCat:        c.docsInCat,
+		Vocab:            c.vocab,
+		WordCountByCat:   c.wordCountByCat,
+		FreqByCat:        c.freqByCat,
+	}
+
+	if err := gob.NewEncoder(w).Encode(&m); err != nil {
+		return fmt.Errorf("encode model: %w", err)
+	}
+
+	return nil
+}
+
+// LoadClassifier reads classifier state from r. It returns ErrModelOutdated if
+// the model was saved in another format, with another processor version or
+// for another fingerprint.
+func LoadClassifier(r io.Reader, processor *Processor, fingerprint string) (*Classifier, error) {
+	var m model
+	if err := gob.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode model: %w", err)
+	}
+
+	switch {
+	case m.FormatVersion != modelFormatVersion:
+		return nil, fmt.Errorf("%w: format version %d, want %d", ErrModelOutdated, m.FormatVersion, modelFormatVersion)
+	case m.ProcessorVersion != processor.Version():
+		return nil, fmt.Errorf("%w: processor version %s, want %s", ErrModelOutdated, m.ProcessorVersion, processor.Version())
+	case m.Fingerprint != fingerprint:
+		return nil, fmt.Errorf("%w: fingerprint %s, want %s", ErrModelOutdated, m.Fingerprint, fingerprint)
+	}
+
+	c := NewClassifier(processor, nil)
+
+	// gob skips empty maps, so copy into maps created by constructor
+	c.totalDocs = m.TotalDocs
+	maps.Copy(c.docsInCat, m.DocsInCat)
+	maps.Copy(c.vocab, m.Vocab)
+	maps.Copy(c.wordCountByCat, m.WordCountByCat)
+
+	for category := range c.docsInCat {
+		c.freqByCat[category] = make(map[string]int)
+		maps.Copy(c.freqByCat[category], m.FreqByCat[category])
+	}
+
+	c.vocabSize = len(c.vocab)
+	c.updateCatProbs()
+
+	return c, nil
+}
+
+// SaveFile atomically writes classifier state to the file at given path.
+func (c *Classifier) SaveFile(path string, fingerprint string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := c.Save(tmp, fingerprint); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadClassifierFile reads classifier state from the file at given path.
+func LoadClassifierFile(path string, processor *Processor, fingerprint string) (*Classifier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadClassifier(file, processor, fingerprint)
+}
@@ -0,0 +1,45 @@
+package nlp_test
+
+import (
+	"bytes"
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	processor, err := nlp.NewProcessor()
+	require.NoError(t, err)
+
+	classifier := nlp.NewClassifier(processor, []domain.Note{
+		{Category: "dev", Content: "golang compiler generics interface"},
+		{Category: "food", Content: "pasta tomato recipe cheese"},
+	})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, classifier.Save(buf, "head"))
+
+	t.Run("same fingerprint", func(t *testing.T) {
+		loaded, err := nlp.LoadClassifier(bytes.NewReader(buf.Bytes()), processor, "head")
+		require.NoError(t, err)
+
+		expectedProbs, expectedCategory := classifier.Classify("tomato cheese")
+		probs, category := loaded.Classify("tomato cheese")
+
+		require.Equal(t, expectedCategory, category)
+		require.InDeltaMapValues(t, expectedProbs, probs, 1e-9)
+
+		// loaded model can be trained further
+		loaded.Learn(domain.Note{Category: "music", Content: "guitar chords"})
+		_, category = loaded.Classify("guitar")
+		require.Equal(t, domain.Category("music"), category)
+	})
+
+	t.Run("another fingerprint", func(t *testing.T) {
+		_, err := nlp.LoadClassifier(bytes.NewReader(buf.Bytes()), processor, "another head")
+		require.ErrorIs(t, err, nlp.ErrModelOutdated)
+	})
+}
@@ -8,6 +8,8 @@ removes common stopwords for English and Russian, and reduces words to their bas
 
 The Classifier is trained on a dataset of labeled documents (notes). Once trained, it can
 predict the category of a new piece of text. It uses logarithmic probabilities for numerical stability.
+The model can be updated incrementally with Learn and Forget, so there is no need to retrain it
+from scratch when notes are added or moved to another category.
 
 Usage:
 
@@ -3,6 +3,7 @@ package nlp
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	_ "embed"
 	"fmt"
 	"strings"
@@ -21,11 +22,16 @@ var (
 	ruStopwordsData []byte
 )
 
+// processorVersion must be incremented on every change of text processing,
+// because it makes models trained with previous versions incompatible.
+const processorVersion = 1
+
 // Processor handles tokenization and lemmatization of text.
 type Processor struct {
 	ruLemmatizer *golem.Lemmatizer
 	enLemmatizer *golem.Lemmatizer
 	stopwords    map[string]struct{}
+	version      string
 }
 
 // NewProcessor creates a new Processor.
@@ -45,13 +51,22 @@ func NewProcessor() (*Processor, error) {
 		return nil, fmt.Errorf("failed to load stopwords: %w", err)
 	}
 
+	stopwordsHash := sha256.Sum256(append(bytes.Clone(enStopwordsData), ruStopwordsData...))
+
 	return &Processor{
 		ruLemmatizer: ruLemmatizer,
 		enLemmatizer: enLemmatizer,
 		stopwords:    stopwords,
+		version:      fmt.Sprintf("%d-%x", processorVersion, stopwordsHash[:4]),
 	}, nil
 }
 
+// Version returns version of text processing. Tokens produced by processors
+// with different versions are not comparable.
+func (p *Processor) Version() string {
+	return p.version
+}
+
 func loadStopwords(datas ...[]byte) (map[string]struct{}, error) {
 	stopwords := make(map[string]struct{})
 	for _, data := range datas {
@@ -0,0 +1,236 @@
+// Package search provides full-text search over notes.
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// BM25 ranking parameters.
+const (
+	k1 = 1.2  // term frequency saturation
+	b  = 0.75 // document length normalization
+)
+
+// Processor is an interface for text tokenization.
+type Processor interface {
+	Process(doc string) []string
+}
+
+// Hit represents a single search result.
+type Hit struct {
+	Note  domain.Note
+	Score float64
+}
+
+type docKey struct {
+	category domain.Category
+	name     string
+}
+
+type document struct {
+	note   domain.Note
+	length int
+	freqs  map[string]int
+}
+
+// Index is an inverted index of notes with BM25 ranking.
+// It is safe for concurrent use.
+type Index struct {
+	processor Processor
+
+	mu       sync.RWMutex
+	docs     map[docKey]*document
+	postings map[string]map[docKey]int
+	totalLen int
+}
+
+// NewIndex creates a new Index and fills it with given notes.
+func NewIndex(processor Processor, notes []domain.Note) *Index {
+	i := &Index{
+		processor: processor,
+		docs:      make(map[docKey]*document),
+		postings:  make(map[string]map[docKey]int),
+	}
+
+	for _, note := range notes {
+		i.Add(note)
+	}
+
+	return i
+}
+
+// Rebuild replaces indexed notes with given ones, e.g. when notes were changed outside of the bot.
+func (i *Index) Rebuild(notes []domain.Note) {
+	docs := make([]*document, 0, len(notes))
+	for _, note := range notes {
+		docs = append(docs, i.document(note))
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.docs = make(map[docKey]*document, len(docs))
+	i.postings = make(map[string]map[docKey]int)
+	i.totalLen = 0
+
+	for _, doc := range docs {
+		i.insert(doc)
+	}
+}
+
+// Add indexes given note. A previously indexed note with the same
+// category and name is replaced.
+func (i *Index) Add(note domain.Note) {
+	doc := i.document(note)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.insert(doc)
+}
+
+// document tokenizes the note for indexing.
+func (i *Index) document(note domain.Note) *document {
+	tokens := i.processor.Process(note.Title + "\n" + note.Content)
+
+	doc := &document{
+		note:   note,
+		length: len(tokens),
+		freqs:  make(map[string]int),
+	}
+
+	for _, token := range tokens {
+		doc.freqs[token]++
+	}
+
+	return doc
+}
+
+// Move changes category of an indexed note.
+func (i *Index) Move(name string, from, to domain.Category) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	doc, ok := i.docs[docKey{category: from, name: name}]
+	if !ok {
+		return
+	}
+
+	i.remove(docKey{category: from, name: name})
+
+	doc.note.Category = to
+	i.insert(doc)
+}
+
+// Remove deletes the note from the index.
+func (i *Index) Remove(note domain.Note) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(docKey{category: note.Category, name: note.Name})
+}
+
+// insert adds document to the index, replacing the one with the same key.
+// Caller must hold the write lock.
+func (i *Index) insert(doc *document) {
+	key := docKey{category: doc.note.Category, name: doc.note.Name}
+
+	i.remove(key)
+
+	i.docs[key] = doc
+	i.totalLen += doc.length
+
+	for term, freq := range doc.freqs {
+		if _, ok := i.postings[term]; !ok {
+			i.postings[term] = make(map[docKey]int)
+		}
+		i.postings[term][key] = freq
+	}
+}
+
+// remove deletes document from the index. Caller must hold the write lock.
+func (i *Index) remove(key docKey) {
+	doc, ok := i.docs[key]
+	if !ok {
+		return
+	}
+
+	for term := range doc.freqs {
+		delete(i.postings[term], key)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+
+	i.totalLen -= doc.length
+	delete(i.docs, key)
+}
+
+// Search returns at most limit notes matching query, ordered by relevance.
+func (i *Index) Search(query string, limit int) []Hit {
+	terms := unique(i.processor.Process(query))
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	docsCount := float64(len(i.docs))
+	if docsCount == 0 || len(terms) == 0 {
+		return nil
+	}
+
+	avgLen := float64(i.totalLen) / docsCount
+	scores := make(map[docKey]float64)
+
+	for _, term := range terms {
+		postings := i.postings[term]
+		df := float64(len(postings))
+		if df == 0 {
+			continue
+		}
+
+		idf := math.Log((docsCount-df+.5)/(df+.5) + 1)
+
+		for key, freq := range postings {
+			tf := float64(freq)
+			norm := 1 - b + b*float64(i.docs[key].length)/avgLen
+			scores[key] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, Hit{Note: i.docs[key].note, Score: score})
+	}
+
+	sort.Slice(hits, func(x, y int) bool {
+		if hits[x].Score != hits[y].Score {
+			return hits[x].Score > hits[y].Score
+		}
+		return hits[x].Note.Name < hits[y].Note.Name
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+func unique(tokens []string) []string {
+	seen := make(map[string]struct{}, len(tokens))
+	result := make([]string, 0, len(tokens))
+
+	for _, token := range tokens {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		result = append(result, token)
+	}
+
+	return result
+}
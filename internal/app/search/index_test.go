@@ -0,0 +1,83 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/search"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fieldsProcessor struct{}
+
+func (fieldsProcessor) Process(doc string) []string {
+	return strings.Fields(strings.ToLower(doc))
+}
+
+func TestSearch(t *testing.T) {
+	notes := []domain.Note{
+		{Title: "go", Name: "go", Category: "dev", Content: "generics in go go go"},
+		{Title: "cooking", Name: "cooking", Category: "home", Content: "pasta recipe"},
+		{Title: "mixed", Name: "mixed", Category: "dev", Content: "go pasta"},
+	}
+
+	index := search.NewIndex(fieldsProcessor{}, notes)
+
+	testCases := []struct {
+		name     string
+		query    string
+		limit    int
+		expected []string
+	}{
+		{name: "single term ranked by frequency", query: "go", expected: []string{"go", "mixed"}},
+		{name: "multiple terms", query: "pasta recipe", expected: []string{"cooking", "mixed"}},
+		{name: "limit", query: "go", limit: 1, expected: []string{"go"}},
+		{name: "unknown term", query: "rust", expected: []string{}},
+		{name: "empty query", query: "", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hits := index.Search(tc.query, tc.limit)
+
+			titles := make([]string, 0, len(hits))
+			for _, hit := range hits {
+				titles = append(titles, hit.Note.Title)
+			}
+
+			require.Equal(t, tc.expected, titles)
+		})
+	}
+}
+
+func TestAddReplacesNote(t *testing.T) {
+	index := search.NewIndex(fieldsProcessor{}, []domain.Note{
+		{Title: "note", Name: "note", Category: "dev", Content: "old content"},
+	})
+
+	index.Add(domain.Note{Title: "note", Name: "note", Category: "dev", Content: "new content"})
+
+	require.Empty(t, index.Search("old", 0))
+
+	hits := index.Search("new", 0)
+	require.Len(t, hits, 1)
+	require.Equal(t, "new content", hits[0].Note.Content)
+}
+
+func TestRebuild(t *testing.T) {
+	index := search.NewIndex(fieldsProcessor{}, []domain.Note{
+		{Title: "removed", Name: "removed", Category: "dev", Content: "old content"},
+	})
+
+	index.Rebuild([]domain.Note{{Title: "pulled", Name: "pulled", Category: "dev", Content: "new content"}})
+
+	require.Empty(t, index.Search("old", 0))
+
+	hits := index.Search("content", 0)
+	require.Len(t, hits, 1)
+	require.Equal(t, "pulled", hits[0].Note.Title)
+}
@@ -0,0 +1,78 @@
+// Package slug provides conversion of note titles into file names.
+package slug
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fallback is used when title has no letters or digits.
+const fallback = "note"
+
+// cyrillic maps lowercase Cyrillic letters to Latin.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+}
+
+// Make returns lowercase file name of at most maxLen bytes made of the title.
+// Cyrillic is transliterated, other letters and digits are kept,
+// the rest characters are replaced with dashes.
+func Make(title string, maxLen int) string {
+	var b strings.Builder
+
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		part, ok := cyrillic[r]
+
+		switch {
+		case ok && part == "":
+			// hard and soft signs are dropped
+			continue
+		case !ok && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case !ok:
+			dash = b.Len() > 0
+			continue
+		}
+
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+
+		b.WriteString(part)
+	}
+
+	slug := truncate(b.String(), maxLen)
+	if slug == "" {
+		return fallback
+	}
+
+	return slug
+}
+
+// truncate cuts slug to maxLen bytes, preferably at the word boundary.
+func truncate(slug string, maxLen int) string {
+	if len(slug) <= maxLen {
+		return slug
+	}
+
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(slug[cut]) {
+		cut--
+	}
+
+	slug = slug[:cut]
+
+	// don't cut too much if the last word is long
+	if i := strings.LastIndexByte(slug, '-'); i > maxLen/2 {
+		slug = slug[:i]
+	}
+
+	return strings.TrimSuffix(slug, "-")
+}
@@ -0,0 +1,35 @@
+package slug_test
+
+import (
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/slug"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMake(t *testing.T) {
+	testCases := []struct {
+		name     string
+		title    string
+		maxLen   int
+		expected string
+	}{
+		{name: "latin", title: "Hello, World!", maxLen: 60, expected: "hello-world"},
+		{name: "colons and parentheses", title: "note (2025-03-01 10:30:00)", maxLen: 60, expected: "note-2025-03-01-10-30-00"},
+		{name: "cyrillic", title: "Съешь ещё этих мягких булок", maxLen: 60, expected: "sesh-eshche-etikh-myagkikh-bulok"},
+		{name: "other letters are kept", title: "Straße café", maxLen: 60, expected: "straße-café"},
+		{name: "cut at word boundary", title: "first second third", maxLen: 15, expected: "first-second"},
+		{name: "cut long word", title: "abcdefghijklmnop", maxLen: 10, expected: "abcdefghij"},
+		{name: "multibyte rune isn't split", title: "ééé", maxLen: 5, expected: "éé"},
+		{name: "no letters", title: "🙂 !!!", maxLen: 60, expected: "note"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, slug.Make(tc.title, tc.maxLen))
+		})
+	}
+}
@@ -97,3 +97,43 @@ func (_c *Classifier_Classify_Call) RunAndReturn(run func(content string) (map[d
 	_c.Call.Return(run)
 	return _c
 }
+
+// Learn provides a mock function for the type Classifier
+func (_mock *Classifier) Learn(note domain.Note) {
+	_mock.Called(note)
+	return
+}
+
+// Classifier_Learn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Learn'
+type Classifier_Learn_Call struct {
+	*mock.Call
+}
+
+// Learn is a helper method to define mock.On call
+//   - note domain.Note
+func (_e *Classifier_Expecter) Learn(note interface{}) *Classifier_Learn_Call {
+	return &Classifier_Learn_Call{Call: _e.mock.On("Learn", note)}
+}
+
+func (_c *Classifier_Learn_Call) Run(run func(note domain.Note)) *Classifier_Learn_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 domain.Note
+		if args[0] != nil {
+			arg0 = args[0].(domain.Note)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Classifier_Learn_Call) Return() *Classifier_Learn_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Classifier_Learn_Call) RunAndReturn(run func(note domain.Note)) *Classifier_Learn_Call {
+	_c.Run(run)
+	return _c
+}
@@ -38,20 +38,35 @@ func (_m *NoteAdder) EXPECT() *NoteAdder_Expecter {
 }
 
 // Add provides a mock function for the type NoteAdder
-func (_mock *NoteAdder) Add(ctx context.Context, note domain.Note) error {
+func (_mock *NoteAdder) Add(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error) {
 	ret := _mock.Called(ctx, note)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Add")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, domain.Note) error); ok {
+	var r0 domain.Note
+	var r1 domain.SaveOutcome
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, domain.Note) (domain.Note, domain.SaveOutcome, error)); ok {
+		return returnFunc(ctx, note)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, domain.Note) domain.Note); ok {
 		r0 = returnFunc(ctx, note)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(domain.Note)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, domain.Note) domain.SaveOutcome); ok {
+		r1 = returnFunc(ctx, note)
+	} else {
+		r1 = ret.Get(1).(domain.SaveOutcome)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, domain.Note) error); ok {
+		r2 = returnFunc(ctx, note)
+	} else {
+		r2 = ret.Error(2)
 	}
-	return r0
+	return r0, r1, r2
 }
 
 // NoteAdder_Add_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Add'
@@ -84,12 +99,12 @@ func (_c *NoteAdder_Add_Call) Run(run func(ctx context.Context, note domain.Note
 	return _c
 }
 
-func (_c *NoteAdder_Add_Call) Return(err error) *NoteAdder_Add_Call {
-	_c.Call.Return(err)
+func (_c *NoteAdder_Add_Call) Return(note domain.Note, saveOutcome domain.SaveOutcome, err error) *NoteAdder_Add_Call {
+	_c.Call.Return(note, saveOutcome, err)
 	return _c
 }
 
-func (_c *NoteAdder_Add_Call) RunAndReturn(run func(ctx context.Context, note domain.Note) error) *NoteAdder_Add_Call {
+func (_c *NoteAdder_Add_Call) RunAndReturn(run func(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error)) *NoteAdder_Add_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -38,8 +38,8 @@ func (_m *NoteSaver) EXPECT() *NoteSaver_Expecter {
 }
 
 // Save provides a mock function for the type NoteSaver
-func (_mock *NoteSaver) Save(ctx context.Context, text string) (models.SaveResult, error) {
-	ret := _mock.Called(ctx, text)
+func (_mock *NoteSaver) Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error) {
+	ret := _mock.Called(ctx, input)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
@@ -47,16 +47,16 @@ func (_mock *NoteSaver) Save(ctx context.Context, text string) (models.SaveResul
 
 	var r0 models.SaveResult
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (models.SaveResult, error)); ok {
-		return returnFunc(ctx, text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.NoteInput) (models.SaveResult, error)); ok {
+		return returnFunc(ctx, input)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) models.SaveResult); ok {
-		r0 = returnFunc(ctx, text)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.NoteInput) models.SaveResult); ok {
+		r0 = returnFunc(ctx, input)
 	} else {
 		r0 = ret.Get(0).(models.SaveResult)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, text)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.NoteInput) error); ok {
+		r1 = returnFunc(ctx, input)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,20 +70,20 @@ type NoteSaver_Save_Call struct {
 
 // Save is a helper method to define mock.On call
 //   - ctx context.Context
-//   - text string
-func (_e *NoteSaver_Expecter) Save(ctx interface{}, text interface{}) *NoteSaver_Save_Call {
-	return &NoteSaver_Save_Call{Call: _e.mock.On("Save", ctx, text)}
+//   - input models.NoteInput
+func (_e *NoteSaver_Expecter) Save(ctx interface{}, input interface{}) *NoteSaver_Save_Call {
+	return &NoteSaver_Save_Call{Call: _e.mock.On("Save", ctx, input)}
 }
 
-func (_c *NoteSaver_Save_Call) Run(run func(ctx context.Context, text string)) *NoteSaver_Save_Call {
+func (_c *NoteSaver_Save_Call) Run(run func(ctx context.Context, input models.NoteInput)) *NoteSaver_Save_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 models.NoteInput
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(models.NoteInput)
 		}
 		run(
 			arg0,
@@ -98,7 +98,7 @@ func (_c *NoteSaver_Save_Call) Return(saveResult models.SaveResult, err error) *
 	return _c
 }
 
-func (_c *NoteSaver_Save_Call) RunAndReturn(run func(ctx context.Context, text string) (models.SaveResult, error)) *NoteSaver_Save_Call {
+func (_c *NoteSaver_Save_Call) RunAndReturn(run func(ctx context.Context, input models.NoteInput) (models.SaveResult, error)) *NoteSaver_Save_Call {
 	_c.Call.Return(run)
 	return _c
 }
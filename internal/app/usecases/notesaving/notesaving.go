@@ -2,11 +2,15 @@
 package notesaving
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/slug"
 	"protomorphine/tg-notes/internal/config"
 	"protomorphine/tg-notes/internal/domain"
 )
@@ -15,14 +19,14 @@ import (
 //
 //mockery:generate: true
 type NoteSaver interface {
-	Save(ctx context.Context, text string) (models.SaveResult, error)
+	Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error)
 }
 
 // NoteAdder is an interface for adding a note.
 //
 //mockery:generate: true
 type NoteAdder interface {
-	Add(ctx context.Context, note domain.Note) error
+	Add(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error)
 }
 
 // Classifier is an interface for text classification.
@@ -30,45 +34,106 @@ type NoteAdder interface {
 //mockery:generate: true
 type Classifier interface {
 	Classify(content string) (map[domain.Category]float64, domain.Category)
+	Learn(note domain.Note)
+}
+
+// Processor is an interface for splitting text into keywords.
+//
+//mockery:generate: true
+type Processor interface {
+	Process(doc string) []string
 }
 
 // Usecase represents the usecase for saving notes.
 type Usecase struct {
 	classifier Classifier
 	adder      NoteAdder
+	processor  Processor
 	cfg        *config.NoteSaveConfig
 }
 
 // New creates a new Usecase.
-func New(adder NoteAdder, classifier Classifier, cfg *config.NoteSaveConfig) *Usecase {
+func New(adder NoteAdder, classifier Classifier, processor Processor, cfg *config.NoteSaveConfig) *Usecase {
 	return &Usecase{
 		cfg:        cfg,
 		adder:      adder,
 		classifier: classifier,
+		processor:  processor,
 	}
 }
 
-// Save saves a new note.
-func (u *Usecase) Save(ctx context.Context, text string) (models.SaveResult, error) {
+// Save saves a new note and feeds it back to the classifier.
+// Note title is made of the note text, file name is made of the title.
+func (u *Usecase) Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error) {
 	const op = "app.usecase.notesaving.Save"
 
-	probs, category := u.classifier.Classify(text)
+	probs, predicted := u.classifier.Classify(input.Text)
 
-	if probs[category] < u.cfg.CategoryThreshold {
+	category := predicted
+	fallback := probs[predicted] < u.cfg.CategoryThreshold
+	if fallback {
 		category = domain.Category(u.cfg.DefaultCategory)
 	}
 
-	title := fmt.Sprintf("note (%v)", time.Now().Format(time.DateTime))
+	now := time.Now()
+	title := u.noteTitle(input.Text, now)
+
+	meta := input.Meta
+	meta.Created = now.Truncate(time.Second)
+	meta.Score = probs[predicted]
 
 	note := domain.Note{
-		Title:    title,
-		Content:  text,
-		Category: category,
+		Title:       title,
+		Name:        slug.Make(title, maxNameLen),
+		Content:     input.Text,
+		Category:    category,
+		Attachments: input.Attachments,
+		Meta:        meta,
 	}
 
-	if err := u.adder.Add(ctx, note); err != nil {
+	note, outcome, err := u.adder.Add(ctx, note)
+	if err != nil {
 		return models.SaveResult{}, fmt.Errorf("%s: error while saving note: %w", op, err)
 	}
 
-	return models.SaveResult{Title: note.Title, Category: note.Category}, nil
+	// the default category isn't learned, otherwise the classifier learns
+	// the notes it's unsure about as ones of the default category;
+	// they are learned when the user chooses category
+	if !fallback {
+		learned := note
+		if outcome == domain.SaveAppended {
+			// the rest of the note is learned already
+			learned.Content = input.Text
+		}
+
+		u.classifier.Learn(learned)
+	}
+
+	return models.SaveResult{
+		Title:      note.Title,
+		Name:       note.Name,
+		Path:       domain.NotePath(note.Category, note.Name),
+		Outcome:    outcome,
+		Category:   note.Category,
+		Fallback:   fallback,
+		Candidates: topCategories(probs, u.cfg.CandidatesCount),
+	}, nil
+}
+
+// topCategories returns at most n categories with the highest probabilities.
+func topCategories(probs map[domain.Category]float64, n int) []domain.Category {
+	categories := slices.Collect(maps.Keys(probs))
+
+	slices.SortFunc(categories, func(a, b domain.Category) int {
+		if c := cmp.Compare(probs[b], probs[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	if len(categories) > n {
+		categories = categories[:n]
+	}
+
+	return categories
 }
@@ -1,9 +1,12 @@
 package notesaving_test
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 
+	"protomorphine/tg-notes/internal/app/models"
 	"protomorphine/tg-notes/internal/app/usecases/notesaving"
 	"protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
 	"protomorphine/tg-notes/internal/config"
@@ -16,33 +19,91 @@ import (
 var (
 	category     domain.Category             = "300 unknown"
 	errAdderMock error                       = errors.New("failed to add")
-	predictions  map[domain.Category]float64 = make(map[domain.Category]float64)
+	predictions  map[domain.Category]float64 = map[domain.Category]float64{category: .5}
+	saveCfg                                  = &config.NoteSaveConfig{CategoryThreshold: .1, DefaultCategory: "default"}
 )
 
 func TestSave(t *testing.T) {
 	testCases := []struct {
-		name            string
-		text            string
-		setupAdder      func(m *mocks.NoteAdder)
-		setupClassifier func(m *mocks.Classifier)
-		expectedErr     error
+		name             string
+		input            models.NoteInput
+		setupAdder       func(m *mocks.NoteAdder)
+		setupClassifier  func(m *mocks.Classifier)
+		expectedFallback bool
+		expectedErr      error
 	}{
 		{
-			name: "success",
-			text: "test note content",
+			name:  "success",
+			input: models.NoteInput{Text: "test note content", Meta: domain.Metadata{ChatID: 42}},
 			setupAdder: func(m *mocks.NoteAdder) {
-				m.EXPECT().Add(mock.Anything, mock.AnythingOfType("domain.Note")).Return(nil).Once()
+				m.EXPECT().Add(mock.Anything, mock.MatchedBy(func(note domain.Note) bool {
+					return note.Meta.ChatID == 42 && note.Meta.Score == .5 && !note.Meta.Created.IsZero()
+				})).RunAndReturn(addNote).Once()
 			},
 			setupClassifier: func(m *mocks.Classifier) {
 				m.EXPECT().Classify(mock.AnythingOfType("string")).Return(predictions, category)
+				m.EXPECT().Learn(mock.AnythingOfType("domain.Note")).Once()
 			},
 			expectedErr: nil,
 		},
 		{
-			name: "adder returns error",
-			text: "test note content",
+			name: "attachments are passed to storage",
+			input: models.NoteInput{
+				Text: "test note content",
+				Attachments: []domain.Attachment{
+					{Name: "photo_1.jpg", Data: []byte("photo")},
+					{Name: "doc.pdf", Data: []byte("pdf")},
+				},
+			},
+			setupAdder: func(m *mocks.NoteAdder) {
+				m.EXPECT().Add(mock.Anything, mock.MatchedBy(func(note domain.Note) bool {
+					return len(note.Attachments) == 2 && note.Content == "test note content"
+				})).RunAndReturn(addNote).Once()
+			},
+			setupClassifier: func(m *mocks.Classifier) {
+				m.EXPECT().Classify("test note content").Return(predictions, category)
+				m.EXPECT().Learn(mock.AnythingOfType("domain.Note")).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "only appended text is learned",
+			input: models.NoteInput{Text: "new text"},
 			setupAdder: func(m *mocks.NoteAdder) {
-				m.EXPECT().Add(mock.Anything, mock.AnythingOfType("domain.Note")).Return(errAdderMock).Once()
+				m.EXPECT().Add(mock.Anything, mock.AnythingOfType("domain.Note")).
+					RunAndReturn(func(_ context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error) {
+						note.Content = "old text\n\nnew text"
+						return note, domain.SaveAppended, nil
+					}).
+					Once()
+			},
+			setupClassifier: func(m *mocks.Classifier) {
+				m.EXPECT().Classify("new text").Return(predictions, category)
+				m.EXPECT().Learn(mock.MatchedBy(func(note domain.Note) bool {
+					return note.Content == "new text"
+				})).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			name:  "default category is used for low probability and isn't learned",
+			input: models.NoteInput{Text: "test note content"},
+			setupAdder: func(m *mocks.NoteAdder) {
+				m.EXPECT().Add(mock.Anything, mock.MatchedBy(func(note domain.Note) bool {
+					return note.Category == domain.Category(saveCfg.DefaultCategory)
+				})).RunAndReturn(addNote).Once()
+			},
+			setupClassifier: func(m *mocks.Classifier) {
+				m.EXPECT().Classify("test note content").Return(map[domain.Category]float64{category: .05}, category)
+			},
+			expectedFallback: true,
+			expectedErr:      nil,
+		},
+		{
+			name:  "adder returns error",
+			input: models.NoteInput{Text: "test note content"},
+			setupAdder: func(m *mocks.NoteAdder) {
+				m.EXPECT().Add(mock.Anything, mock.AnythingOfType("domain.Note")).Return(domain.Note{}, "", errAdderMock).Once()
 			},
 			setupClassifier: func(m *mocks.Classifier) {
 				m.EXPECT().Classify(mock.AnythingOfType("string")).Return(predictions, category)
@@ -51,8 +112,6 @@ func TestSave(t *testing.T) {
 		},
 	}
 
-	predictions[category] = .5
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -63,14 +122,97 @@ func TestSave(t *testing.T) {
 			mockClassifier := mocks.NewClassifier(t)
 			tc.setupClassifier(mockClassifier)
 
-			uc := notesaving.New(mockAdder, mockClassifier, &config.NoteSaveConfig{CategoryThreshold: .1, DefaultCategory: "default"})
-			_, err := uc.Save(t.Context(), tc.text)
+			uc := notesaving.New(mockAdder, mockClassifier, mocks.NewProcessor(t), saveCfg)
+			res, err := uc.Save(t.Context(), tc.input)
 
 			if tc.expectedErr != nil {
 				require.ErrorIs(t, err, tc.expectedErr)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tc.expectedFallback, res.Fallback)
 			}
 		})
 	}
 }
+
+func TestTitle(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		keywords      []string
+		expectedTitle string
+		expectedName  string
+	}{
+		{
+			name:          "first line",
+			text:          "Buy milk: 2 bottles\nand bread",
+			expectedTitle: "Buy milk: 2 bottles",
+			expectedName:  "buy-milk-2-bottles",
+		},
+		{
+			name:          "heading is preferred",
+			text:          "intro **text**\n\n## Release *plan* ##\nsteps",
+			expectedTitle: "Release plan",
+			expectedName:  "release-plan",
+		},
+		{
+			name:          "markdown is removed",
+			text:          "> see [the docs](https://example.com) for `go test`",
+			expectedTitle: "see the docs for go test",
+			expectedName:  "see-the-docs-for-go-test",
+		},
+		{
+			name:          "cyrillic is transliterated",
+			text:          "Щука съела ёжика",
+			expectedTitle: "Щука съела ёжика",
+			expectedName:  "shchuka-sela-ezhika",
+		},
+		{
+			name:          "long line is truncated",
+			text:          strings.Repeat("word ", 30),
+			expectedTitle: strings.TrimSpace(strings.Repeat("word ", 15)) + "…",
+			expectedName:  strings.TrimSuffix(strings.Repeat("word-", 12), "-"),
+		},
+		{
+			name:          "keywords when first line has no words",
+			text:          "https://example.com/page",
+			keywords:      []string{"example", "page", "com", "example"},
+			expectedTitle: "example page com",
+			expectedName:  "example-page-com",
+		},
+		{
+			name:          "creation time when there are no keywords",
+			text:          "",
+			expectedTitle: "note ",
+			expectedName:  "note-",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			processor := mocks.NewProcessor(t)
+			processor.EXPECT().Process(tc.text).Return(tc.keywords).Maybe()
+
+			classifier := mocks.NewClassifier(t)
+			classifier.EXPECT().Classify(tc.text).Return(predictions, category)
+			classifier.EXPECT().Learn(mock.AnythingOfType("domain.Note"))
+
+			adder := mocks.NewNoteAdder(t)
+			adder.EXPECT().Add(mock.Anything, mock.AnythingOfType("domain.Note")).RunAndReturn(addNote).Once()
+
+			uc := notesaving.New(adder, classifier, processor, saveCfg)
+			res, err := uc.Save(t.Context(), models.NoteInput{Text: tc.text})
+			require.NoError(t, err)
+
+			require.True(t, strings.HasPrefix(res.Title, tc.expectedTitle), res.Title)
+			require.True(t, strings.HasPrefix(res.Name, tc.expectedName), res.Name)
+			require.Equal(t, string(category)+"/"+res.Name+".md", res.Path)
+		})
+	}
+}
+
+func addNote(_ context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error) {
+	return note, domain.SaveCreated, nil
+}
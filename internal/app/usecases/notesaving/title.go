@@ -0,0 +1,137 @@
+package notesaving
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxTitleLen   = 80 // max title length in runes
+	maxNameLen    = 60 // max note file name length in bytes
+	keywordsCount = 3  // count of keywords in title made of keywords
+)
+
+var (
+	headingRe = regexp.MustCompile(`^#{1,6}\s+(.*?)[\s#]*$`)
+	linkRe    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	urlRe     = regexp.MustCompile(`https?://\S+|tg://\S+`)
+	listRe    = regexp.MustCompile(`^(?:[-+*]|\d+[.)])\s+`)
+	// underscores inside words aren't emphasis
+	underscoreRe = regexp.MustCompile(`(^|\s)_+|_+(\s|$)`)
+)
+
+// noteTitle makes title of the note text. The first Markdown heading is used,
+// otherwise the first line. If there are no words, the title is made of
+// the most frequent keywords or of the creation time.
+func (u *Usecase) noteTitle(text string, now time.Time) string {
+	if title := firstLine(text); title != "" {
+		return title
+	}
+
+	if keywords := u.keywords(text); len(keywords) > 0 {
+		return strings.Join(keywords, " ")
+	}
+
+	return fmt.Sprintf("note %v", now.Format(time.DateTime))
+}
+
+// firstLine returns the first heading or the first line with words,
+// cleared of Markdown, outside of code blocks.
+func firstLine(text string) string {
+	var first string
+	inCode := false
+
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+
+		if inCode {
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			if heading := cleanLine(m[1]); heading != "" {
+				return heading
+			}
+			continue
+		}
+
+		if first == "" {
+			first = cleanLine(line)
+		}
+	}
+
+	return first
+}
+
+// cleanLine removes Markdown markup and URLs from the line and limits its length.
+// Returns empty string if there are no letters or digits left.
+func cleanLine(line string) string {
+	line = strings.TrimLeft(line, "> ")
+	line = listRe.ReplaceAllString(line, "")
+	line = linkRe.ReplaceAllString(line, "$1")
+	line = urlRe.ReplaceAllString(line, "")
+	line = strings.NewReplacer("*", "", "~~", "", "`", "").Replace(line)
+	line = underscoreRe.ReplaceAllString(line, "$1$2")
+	line = strings.Join(strings.Fields(line), " ")
+
+	if !strings.ContainsFunc(line, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return ""
+	}
+
+	return truncate(line, maxTitleLen)
+}
+
+// keywords returns the most frequent words of the text in order of frequency.
+func (u *Usecase) keywords(text string) []string {
+	freqs := make(map[string]int)
+	order := make(map[string]int)
+
+	for _, token := range u.processor.Process(text) {
+		token = strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) })
+		if token == "" {
+			continue
+		}
+
+		if _, ok := order[token]; !ok {
+			order[token] = len(order)
+		}
+		freqs[token]++
+	}
+
+	keywords := slices.Collect(maps.Keys(freqs))
+	slices.SortFunc(keywords, func(a, b string) int {
+		return cmp.Or(cmp.Compare(freqs[b], freqs[a]), cmp.Compare(order[a], order[b]))
+	})
+
+	if len(keywords) > keywordsCount {
+		keywords = keywords[:keywordsCount]
+	}
+
+	return keywords
+}
+
+// truncate cuts text to n runes at the word boundary and adds ellipsis.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	cut := string(runes[:n-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
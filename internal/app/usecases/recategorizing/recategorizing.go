@@ -0,0 +1,98 @@
+// Package recategorizing provides usecase for changing category of saved notes
+package recategorizing
+
+import (
+	"context"
+	"fmt"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// NoteRecategorizer is an interface for changing category of saved notes.
+//
+//mockery:generate: true
+type NoteRecategorizer interface {
+	Recategorize(ctx context.Context, note models.SaveResult, category domain.Category) (models.SaveResult, error)
+	Categories(ctx context.Context) ([]domain.Category, error)
+}
+
+// NoteMover is an interface for moving a note to another category.
+//
+//mockery:generate: true
+type NoteMover interface {
+	Move(ctx context.Context, name string, from, to domain.Category) (domain.Note, error)
+}
+
+// CategoryLister is an interface for listing all known categories.
+//
+//mockery:generate: true
+type CategoryLister interface {
+	Categories(ctx context.Context) ([]domain.Category, error)
+}
+
+// Learner is an interface for updating classifier with moved notes.
+//
+//mockery:generate: true
+type Learner interface {
+	Learn(note domain.Note)
+	Forget(note domain.Note)
+}
+
+// Usecase represents the usecase for changing category of saved notes.
+type Usecase struct {
+	mover   NoteMover
+	lister  CategoryLister
+	learner Learner
+}
+
+// New creates a new Usecase.
+func New(mover NoteMover, lister CategoryLister, learner Learner) *Usecase {
+	return &Usecase{
+		mover:   mover,
+		lister:  lister,
+		learner: learner,
+	}
+}
+
+// Recategorize moves saved note to given category and feeds it back to the classifier.
+func (u *Usecase) Recategorize(ctx context.Context, note models.SaveResult, category domain.Category) (models.SaveResult, error) {
+	const op = "app.usecase.recategorizing.Recategorize"
+
+	if note.Category == category {
+		return note, nil
+	}
+
+	moved, err := u.mover.Move(ctx, note.Name, note.Category, category)
+	if err != nil {
+		return models.SaveResult{}, fmt.Errorf("%s: error while moving note: %w", op, err)
+	}
+
+	// note saved to the default category wasn't learned, see notesaving.Usecase.Save
+	if !note.Fallback {
+		forgotten := moved
+		forgotten.Category = note.Category
+
+		u.learner.Forget(forgotten)
+	}
+
+	u.learner.Learn(moved)
+
+	note.Category = category
+	note.Fallback = false
+	note.Path = domain.NotePath(category, note.Name)
+
+	return note, nil
+}
+
+// Categories returns all known categories.
+func (u *Usecase) Categories(ctx context.Context) ([]domain.Category, error) {
+	const op = "app.usecase.recategorizing.Categories"
+
+	categories, err := u.lister.Categories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while listing categories: %w", op, err)
+	}
+
+	return categories, nil
+}
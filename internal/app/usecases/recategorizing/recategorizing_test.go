@@ -0,0 +1,58 @@
+package recategorizing_test
+
+import (
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/usecases/recategorizing"
+	"protomorphine/tg-notes/internal/app/usecases/recategorizing/mocks"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecategorize(t *testing.T) {
+	tests := []struct {
+		name         string
+		fallback     bool
+		setupLearner func(*mocks.Learner)
+	}{
+		{
+			name: "predicted category is forgotten",
+			setupLearner: func(m *mocks.Learner) {
+				m.EXPECT().Forget(mock.MatchedBy(func(note domain.Note) bool { return note.Category == "dev" })).Once()
+				m.EXPECT().Learn(mock.MatchedBy(func(note domain.Note) bool { return note.Category == "home" })).Once()
+			},
+		},
+		{
+			name:     "default category wasn't learned, so it isn't forgotten",
+			fallback: true,
+			setupLearner: func(m *mocks.Learner) {
+				m.EXPECT().Learn(mock.MatchedBy(func(note domain.Note) bool { return note.Category == "home" })).Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mover := mocks.NewNoteMover(t)
+			mover.EXPECT().Move(mock.Anything, "note", domain.Category("dev"), domain.Category("home")).
+				Return(domain.Note{Name: "note", Category: "home", Content: "text"}, nil).
+				Once()
+
+			learner := mocks.NewLearner(t)
+			tc.setupLearner(learner)
+
+			u := recategorizing.New(mover, mocks.NewCategoryLister(t), learner)
+
+			res, err := u.Recategorize(t.Context(), models.SaveResult{Name: "note", Category: "dev", Fallback: tc.fallback}, "home")
+			require.NoError(t, err)
+			require.Equal(t, domain.Category("home"), res.Category)
+			require.Equal(t, "home/note.md", res.Path)
+			require.False(t, res.Fallback)
+		})
+	}
+}
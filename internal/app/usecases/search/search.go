@@ -0,0 +1,92 @@
+// Package search provides usecase for searching notes
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/search"
+	"protomorphine/tg-notes/internal/config"
+)
+
+// NoteSearcher is an interface for searching notes.
+//
+//mockery:generate: true
+type NoteSearcher interface {
+	Search(ctx context.Context, query string) []models.SearchResult
+}
+
+// Index is an interface for full-text index of notes.
+//
+//mockery:generate: true
+type Index interface {
+	Search(query string, limit int) []search.Hit
+}
+
+// Usecase represents the usecase for searching notes.
+type Usecase struct {
+	index Index
+	cfg   *config.SearchConfig
+}
+
+// New creates a new Usecase.
+func New(index Index, cfg *config.SearchConfig) *Usecase {
+	return &Usecase{
+		index: index,
+		cfg:   cfg,
+	}
+}
+
+// Search returns the most relevant notes for given query.
+func (u *Usecase) Search(ctx context.Context, query string) []models.SearchResult {
+	hits := u.index.Search(query, u.cfg.ResultsLimit)
+
+	results := make([]models.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, models.SearchResult{
+			Title:    hit.Note.Title,
+			Category: hit.Note.Category,
+			Snippet:  snippet(hit.Note.Content, query, u.cfg.SnippetLength),
+		})
+	}
+
+	return results
+}
+
+// snippet returns the first line of content containing any of the query words,
+// or the first non-empty line if there is no such line. Snippet is truncated to maxLen runes.
+func snippet(content, query string, maxLen int) string {
+	words := strings.Fields(strings.ToLower(query))
+
+	var first string
+
+	for line := range strings.Lines(content) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if first == "" {
+			first = line
+		}
+
+		lower := strings.ToLower(line)
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				return truncate(line, maxLen)
+			}
+		}
+	}
+
+	return truncate(first, maxLen)
+}
+
+func truncate(s string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+
+	return string([]rune(s)[:maxLen]) + "…"
+}
@@ -0,0 +1,157 @@
+// Package users provides registry, which routes requests to usecases of the user who made them.
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/usecases/notesaving"
+	"protomorphine/tg-notes/internal/app/usecases/recategorizing"
+	"protomorphine/tg-notes/internal/app/usecases/search"
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// ErrUnknownUser is returned when request is made by user, which isn't registered.
+var ErrUnknownUser = errors.New("unknown user")
+
+type userIDContextKey struct{}
+
+// WithID returns a copy of ctx with given user ID.
+func WithID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, id)
+}
+
+// ID retrieves user ID from given context.
+func ID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDContextKey{}).(int64)
+	return id, ok
+}
+
+// NoteStorage is an interface of the user's notes storage.
+//
+//mockery:generate: true
+type NoteStorage interface {
+	Notes(ctx context.Context) ([]domain.Note, error)
+	Sync(ctx context.Context) error
+}
+
+// Usecases represents usecases of a single user.
+type Usecases struct {
+	Saver         notesaving.NoteSaver
+	Recategorizer recategorizing.NoteRecategorizer
+	Searcher      search.NoteSearcher
+	Storage       NoteStorage
+}
+
+// Registry stores usecases of all users. Users must be registered before
+// the registry is used to route requests.
+type Registry struct {
+	users map[int64]*Usecases
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{users: make(map[int64]*Usecases)}
+}
+
+// Register adds usecases of the user with given ID.
+func (r *Registry) Register(id int64, usecases *Usecases) {
+	r.users[id] = usecases
+}
+
+// Has reports whether user with given ID is registered.
+func (r *Registry) Has(id int64) bool {
+	_, ok := r.users[id]
+	return ok
+}
+
+// IDs returns sorted IDs of all registered users.
+func (r *Registry) IDs() []int64 {
+	ids := make([]int64, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+// Save saves a new note with the usecase of the user from context.
+func (r *Registry) Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error) {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return models.SaveResult{}, err
+	}
+
+	return usecases.Saver.Save(ctx, input)
+}
+
+// Recategorize moves a note with the usecase of the user from context.
+func (r *Registry) Recategorize(ctx context.Context, note models.SaveResult, category domain.Category) (models.SaveResult, error) {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return models.SaveResult{}, err
+	}
+
+	return usecases.Recategorizer.Recategorize(ctx, note, category)
+}
+
+// Categories returns categories with the usecase of the user from context.
+func (r *Registry) Categories(ctx context.Context) ([]domain.Category, error) {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecases.Recategorizer.Categories(ctx)
+}
+
+// Notes returns notes from the storage of the user from context.
+func (r *Registry) Notes(ctx context.Context) ([]domain.Note, error) {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecases.Storage.Notes(ctx)
+}
+
+// Sync saves pending changes of the storage of the user from context.
+func (r *Registry) Sync(ctx context.Context) error {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return usecases.Storage.Sync(ctx)
+}
+
+// Search searches notes with the usecase of the user from context.
+// Nothing is found for unknown user.
+func (r *Registry) Search(ctx context.Context, query string) []models.SearchResult {
+	usecases, err := r.get(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return usecases.Searcher.Search(ctx, query)
+}
+
+func (r *Registry) get(ctx context.Context) (*Usecases, error) {
+	const op = "app.users.get"
+
+	id, ok := ID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: no user ID in context", op, ErrUnknownUser)
+	}
+
+	usecases, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %d", op, ErrUnknownUser, id)
+	}
+
+	return usecases, nil
+}
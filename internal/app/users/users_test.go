@@ -0,0 +1,85 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/models"
+	nsmocks "protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
+	smocks "protomorphine/tg-notes/internal/app/usecases/search/mocks"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/app/users/mocks"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouting(t *testing.T) {
+	first := nsmocks.NewNoteSaver(t)
+	second := nsmocks.NewNoteSaver(t)
+
+	second.EXPECT().Save(mock.Anything, models.NoteInput{Text: "text"}).Return(models.SaveResult{Title: "second"}, nil).Once()
+
+	registry := users.NewRegistry()
+	registry.Register(1, &users.Usecases{Saver: first})
+	registry.Register(2, &users.Usecases{Saver: second})
+
+	require.True(t, registry.Has(1))
+	require.False(t, registry.Has(3))
+	require.Equal(t, []int64{1, 2}, registry.IDs())
+
+	res, err := registry.Save(users.WithID(t.Context(), 2), models.NoteInput{Text: "text"})
+	require.NoError(t, err)
+	require.Equal(t, "second", res.Title)
+}
+
+func TestStorageRouting(t *testing.T) {
+	first := mocks.NewNoteStorage(t)
+	second := mocks.NewNoteStorage(t)
+
+	first.EXPECT().Notes(mock.Anything).Return([]domain.Note{{Name: "first"}}, nil).Once()
+	second.EXPECT().Sync(mock.Anything).Return(nil).Once()
+
+	registry := users.NewRegistry()
+	registry.Register(1, &users.Usecases{Storage: first})
+	registry.Register(2, &users.Usecases{Storage: second})
+
+	notes, err := registry.Notes(users.WithID(t.Context(), 1))
+	require.NoError(t, err)
+	require.Equal(t, []domain.Note{{Name: "first"}}, notes)
+
+	require.NoError(t, registry.Sync(users.WithID(t.Context(), 2)))
+}
+
+func TestUnknownUser(t *testing.T) {
+	searcher := smocks.NewNoteSearcher(t)
+
+	registry := users.NewRegistry()
+	registry.Register(1, &users.Usecases{Searcher: searcher})
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no user in context", ctx: t.Context()},
+		{name: "user isn't registered", ctx: users.WithID(t.Context(), 2)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := registry.Save(tc.ctx, models.NoteInput{Text: "text"})
+			require.ErrorIs(t, err, users.ErrUnknownUser)
+
+			_, err = registry.Categories(tc.ctx)
+			require.ErrorIs(t, err, users.ErrUnknownUser)
+
+			_, err = registry.Notes(tc.ctx)
+			require.ErrorIs(t, err, users.ErrUnknownUser)
+
+			require.ErrorIs(t, registry.Sync(tc.ctx), users.ErrUnknownUser)
+
+			require.Empty(t, registry.Search(tc.ctx, "query"))
+		})
+	}
+}
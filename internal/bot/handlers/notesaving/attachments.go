@@ -0,0 +1,144 @@
+package notesaving
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+var (
+	errAttachmentTooBig     = errors.New("attachment is too big")
+	errAttachmentNotAllowed = errors.New("attachment type isn't allowed")
+)
+
+// FileGetter is an interface for getting files sent to the bot.
+//
+//mockery:generate: true
+type FileGetter interface {
+	GetFile(ctx context.Context, params *bot.GetFileParams) (*models.File, error)
+	FileDownloadLink(f *models.File) string
+}
+
+// attachmentRef describes a file attached to the message, which isn't downloaded yet.
+type attachmentRef struct {
+	fileID   string
+	name     string
+	mimeType string
+	size     int64
+}
+
+// messageAttachments returns photo, document and voice attached to the message.
+func messageAttachments(message *models.Message) []attachmentRef {
+	var refs []attachmentRef
+
+	// photo is sent in several sizes, the last one is the largest
+	if len(message.Photo) > 0 {
+		photo := message.Photo[len(message.Photo)-1]
+		refs = append(refs, attachmentRef{
+			fileID:   photo.FileID,
+			name:     "photo_" + photo.FileUniqueID + ".jpg",
+			mimeType: "image/jpeg",
+			size:     int64(photo.FileSize),
+		})
+	}
+
+	if document := message.Document; document != nil {
+		// hidden names and ".." are replaced, so attachments don't escape the assets directory
+		name := path.Base(strings.ReplaceAll(document.FileName, "\\", "/"))
+		if name == "/" || strings.HasPrefix(name, ".") {
+			name = "document_" + document.FileUniqueID
+		}
+
+		refs = append(refs, attachmentRef{
+			fileID:   document.FileID,
+			name:     name,
+			mimeType: document.MimeType,
+			size:     document.FileSize,
+		})
+	}
+
+	if voice := message.Voice; voice != nil {
+		mimeType := voice.MimeType
+		if mimeType == "" {
+			mimeType = "audio/ogg"
+		}
+
+		refs = append(refs, attachmentRef{
+			fileID:   voice.FileID,
+			name:     "voice_" + voice.FileUniqueID + ".ogg",
+			mimeType: mimeType,
+			size:     voice.FileSize,
+		})
+	}
+
+	return refs
+}
+
+// downloadAttachment checks attachment against configured limits and downloads it.
+func downloadAttachment(
+	ctx context.Context,
+	getter FileGetter,
+	cfg *config.AttachmentsConfig,
+	ref attachmentRef,
+) (domain.Attachment, error) {
+	if !mimeTypeAllowed(cfg.AllowedTypes, ref.mimeType) {
+		return domain.Attachment{}, fmt.Errorf("%w: %s", errAttachmentNotAllowed, ref.mimeType)
+	}
+
+	if ref.size > cfg.MaxSize {
+		return domain.Attachment{}, fmt.Errorf("%w: %d bytes", errAttachmentTooBig, ref.size)
+	}
+
+	file, err := getter.GetFile(ctx, &bot.GetFileParams{FileID: ref.fileID})
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("get file error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getter.FileDownloadLink(file), nil)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("download error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.Attachment{}, fmt.Errorf("download error: unexpected status %s", resp.Status)
+	}
+
+	// size from the message may be absent, so limit the body as well
+	data, err := io.ReadAll(io.LimitReader(resp.Body, cfg.MaxSize+1))
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("download error: %w", err)
+	}
+
+	if int64(len(data)) > cfg.MaxSize {
+		return domain.Attachment{}, errAttachmentTooBig
+	}
+
+	return domain.Attachment{Name: ref.name, Data: data}, nil
+}
+
+// mimeTypeAllowed reports whether MIME type matches any of allowed patterns, e.g. "image/*".
+func mimeTypeAllowed(allowed []string, mimeType string) bool {
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), mimeType); ok {
+			return true
+		}
+	}
+
+	return false
+}
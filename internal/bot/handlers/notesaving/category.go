@@ -0,0 +1,147 @@
+package notesaving
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"protomorphine/tg-notes/internal/app/usecases/recategorizing"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/bot/middleware"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CallbackResponder is an interface for responding to callback queries.
+//
+//mockery:generate: true
+type CallbackResponder interface {
+	AnswerCallbackQuery(ctx context.Context, params *bot.AnswerCallbackQueryParams) (bool, error)
+	EditMessageText(ctx context.Context, params *bot.EditMessageTextParams) (*models.Message, error)
+	EditMessageReplyMarkup(ctx context.Context, params *bot.EditMessageReplyMarkupParams) (*models.Message, error)
+}
+
+// CallbackHandler represents the category keyboard callback handler for the bot.
+type CallbackHandler func(ctx context.Context, responder CallbackResponder, update *models.Update)
+
+// NewCategoryCallback creates a new CallbackHandler, which moves pending note
+// to the category chosen via inline keyboard.
+func NewCategoryCallback(logger *slog.Logger, recategorizer recategorizing.NoteRecategorizer, pending *PendingNotes) CallbackHandler {
+	return func(ctx context.Context, responder CallbackResponder, update *models.Update) {
+		const op = "bot.handlers.category"
+		logger := logger.With(log.Op(op), log.ReqID(middleware.GetReqID(ctx)))
+
+		query := update.CallbackQuery
+		if query == nil {
+			logger.Warn("nil callback query received")
+			return
+		}
+
+		var answer string
+		defer func() {
+			_, err := responder.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: query.ID,
+				Text:            answer,
+			})
+			if err != nil {
+				logger.Error("error occured while answering callback query", log.Err(err))
+			}
+		}()
+
+		id, option, _ := strings.Cut(strings.TrimPrefix(query.Data, CategoryCallbackPrefix), ":")
+
+		// notes of other users are unknown, so they can't be moved by forged callbacks
+		owner, _ := users.ID(ctx)
+
+		note, ok := pending.get(owner, id)
+		if !ok {
+			logger.Warn("callback for unknown note received", slog.String("data", query.Data))
+			answer = renderOrEmpty(logger, categoryExpiredTemplate, struct{}{})
+			return
+		}
+
+		// message is inaccessible if it is too old, nothing to edit then
+		message := query.Message.Message
+
+		if option == otherOption {
+			categories, err := recategorizer.Categories(ctx)
+			if err != nil {
+				logger.Error("error occured while listing categories", log.Err(err))
+				answer = renderOrEmpty(logger, categoryErrTemplate, struct{}{})
+				return
+			}
+
+			// Telegram rejects larger keyboards, the rest of categories can't be chosen
+			if len(categories) > maxKeyboardButtons {
+				logger.Warn("too many categories, only the first are offered",
+					slog.Int("count", len(categories)), slog.Int("offered", maxKeyboardButtons))
+				categories = categories[:maxKeyboardButtons]
+			}
+
+			pending.setOptions(id, categories)
+			note.options = categories
+
+			if message != nil {
+				_, err := responder.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+					ChatID:      message.Chat.ID,
+					MessageID:   message.ID,
+					ReplyMarkup: categoryKeyboard(id, note, false),
+				})
+				if err != nil {
+					logger.Error("error occured while editing message", log.Err(err))
+				}
+			}
+
+			return
+		}
+
+		i, err := strconv.Atoi(option)
+		if err != nil || i < 0 || i >= len(note.options) {
+			logger.Warn("callback with invalid option received", slog.String("data", query.Data))
+			answer = renderOrEmpty(logger, categoryExpiredTemplate, struct{}{})
+			return
+		}
+
+		res, err := recategorizer.Recategorize(ctx, note.note, note.options[i])
+		if err != nil {
+			logger.Error("error occured while changing note category", log.Err(err))
+			answer = renderOrEmpty(logger, categoryErrTemplate, struct{}{})
+			return
+		}
+
+		pending.remove(id)
+		logger.Info("note category changed", slog.String("category", string(res.Category)))
+
+		if message == nil {
+			return
+		}
+
+		text, err := render(successTemplate, saveSuccess{SaveResult: res})
+		if err != nil {
+			logger.Error("error while rendering template", log.Err(err))
+			return
+		}
+
+		_, err = responder.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    message.Chat.ID,
+			MessageID: message.ID,
+			Text:      text,
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+		if err != nil {
+			logger.Error("error occured while editing message", log.Err(err))
+		}
+	}
+}
+
+func renderOrEmpty(logger *slog.Logger, templatePath string, args any) string {
+	text, err := render(templatePath, args)
+	if err != nil {
+		logger.Error("error while rendering template", log.Err(err))
+	}
+
+	return text
+}
@@ -0,0 +1,209 @@
+package notesaving_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	appmodels "protomorphine/tg-notes/internal/app/models"
+	ucmocks "protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
+	rcmocks "protomorphine/tg-notes/internal/app/usecases/recategorizing/mocks"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/bot/handlers/notesaving"
+	"protomorphine/tg-notes/internal/bot/handlers/notesaving/mocks"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var savedNote = appmodels.SaveResult{
+	Title:      "note",
+	Category:   "default",
+	Candidates: []domain.Category{"dev", "home"},
+}
+
+// savePendingNote saves a note of the user from ctx via notesaving handler, so it becomes pending.
+// IDs of pending notes are numbered from "1" in order of saving.
+func savePendingNote(ctx context.Context, t *testing.T, pending *notesaving.PendingNotes) {
+	t.Helper()
+
+	saver := ucmocks.NewNoteSaver(t)
+	saver.EXPECT().Save(mock.Anything, appmodels.NoteInput{Text: "text"}).Return(savedNote, nil).Once()
+
+	sender := mocks.NewBotAPI(t)
+	sender.EXPECT().
+		SendMessage(mock.Anything, mock.AnythingOfType("*bot.SendMessageParams")).
+		Run(func(_ context.Context, params *bot.SendMessageParams) {
+			keyboard, ok := params.ReplyMarkup.(*models.InlineKeyboardMarkup)
+			require.True(t, ok)
+			require.Len(t, keyboard.InlineKeyboard, len(savedNote.Candidates)+1)
+		}).
+		Return(nil, nil).
+		Once()
+
+	h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, pending, attachmentsCfg)
+	h(ctx, sender, &models.Update{Message: &models.Message{Text: "text"}})
+}
+
+func callbackUpdate(data string) *models.Update {
+	return &models.Update{
+		CallbackQuery: &models.CallbackQuery{
+			ID:   "query",
+			Data: data,
+			Message: models.MaybeInaccessibleMessage{
+				Message: &models.Message{ID: 1},
+			},
+		},
+	}
+}
+
+func TestCategoryCallback(t *testing.T) {
+	tests := []struct {
+		name               string
+		data               string
+		userID             int64 // user, who sent the callback; the note is saved by user 1
+		setupRecategorizer func(*rcmocks.NoteRecategorizer)
+		setupResponder     func(*mocks.CallbackResponder)
+	}{
+		{
+			name:               "unknown note",
+			data:               "category:42:0",
+			setupRecategorizer: func(*rcmocks.NoteRecategorizer) {},
+			setupResponder:     func(*mocks.CallbackResponder) {},
+		},
+		{
+			name:               "note of another user",
+			data:               "category:1:1",
+			userID:             2,
+			setupRecategorizer: func(*rcmocks.NoteRecategorizer) {},
+			setupResponder:     func(*mocks.CallbackResponder) {},
+		},
+		{
+			name:               "invalid option",
+			data:               "category:1:5",
+			setupRecategorizer: func(*rcmocks.NoteRecategorizer) {},
+			setupResponder:     func(*mocks.CallbackResponder) {},
+		},
+		{
+			name: "category chosen",
+			data: "category:1:1",
+			setupRecategorizer: func(m *rcmocks.NoteRecategorizer) {
+				moved := savedNote
+				moved.Category = "home"
+
+				m.EXPECT().Recategorize(mock.Anything, savedNote, domain.Category("home")).Return(moved, nil).Once()
+			},
+			setupResponder: func(m *mocks.CallbackResponder) {
+				m.EXPECT().
+					EditMessageText(mock.Anything, mock.AnythingOfType("*bot.EditMessageTextParams")).
+					Run(func(_ context.Context, params *bot.EditMessageTextParams) {
+						require.Contains(t, params.Text, "home")
+					}).
+					Return(nil, nil).
+					Once()
+			},
+		},
+		{
+			name: "recategorize returns error",
+			data: "category:1:0",
+			setupRecategorizer: func(m *rcmocks.NoteRecategorizer) {
+				m.EXPECT().Recategorize(mock.Anything, savedNote, domain.Category("dev")).
+					Return(appmodels.SaveResult{}, errors.New("move error")).
+					Once()
+			},
+			setupResponder: func(*mocks.CallbackResponder) {},
+		},
+		{
+			name: "other categories requested",
+			data: "category:1:other",
+			setupRecategorizer: func(m *rcmocks.NoteRecategorizer) {
+				m.EXPECT().Categories(mock.Anything).Return([]domain.Category{"a", "b", "c"}, nil).Once()
+			},
+			setupResponder: func(m *mocks.CallbackResponder) {
+				m.EXPECT().
+					EditMessageReplyMarkup(mock.Anything, mock.AnythingOfType("*bot.EditMessageReplyMarkupParams")).
+					Run(func(_ context.Context, params *bot.EditMessageReplyMarkupParams) {
+						keyboard, ok := params.ReplyMarkup.(*models.InlineKeyboardMarkup)
+						require.True(t, ok)
+						require.Len(t, keyboard.InlineKeyboard, 3)
+					}).
+					Return(nil, nil).
+					Once()
+			},
+		},
+		{
+			name: "too many categories requested",
+			data: "category:1:other",
+			setupRecategorizer: func(m *rcmocks.NoteRecategorizer) {
+				categories := make([]domain.Category, 150)
+				for i := range categories {
+					categories[i] = domain.Category(fmt.Sprintf("category-%d", i))
+				}
+
+				m.EXPECT().Categories(mock.Anything).Return(categories, nil).Once()
+			},
+			setupResponder: func(m *mocks.CallbackResponder) {
+				m.EXPECT().
+					EditMessageReplyMarkup(mock.Anything, mock.AnythingOfType("*bot.EditMessageReplyMarkupParams")).
+					Run(func(_ context.Context, params *bot.EditMessageReplyMarkupParams) {
+						keyboard, ok := params.ReplyMarkup.(*models.InlineKeyboardMarkup)
+						require.True(t, ok)
+						require.Len(t, keyboard.InlineKeyboard, 100)
+					}).
+					Return(nil, nil).
+					Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pending := notesaving.NewPendingNotes(10)
+			savePendingNote(users.WithID(t.Context(), 1), t, pending)
+
+			userID := tc.userID
+			if userID == 0 {
+				userID = 1
+			}
+
+			recategorizer := rcmocks.NewNoteRecategorizer(t)
+			tc.setupRecategorizer(recategorizer)
+
+			responder := mocks.NewCallbackResponder(t)
+			tc.setupResponder(responder)
+			responder.EXPECT().AnswerCallbackQuery(mock.Anything, mock.Anything).Return(true, nil).Once()
+
+			h := notesaving.NewCategoryCallback(slog.New(log.NewDiscardHandler()), recategorizer, pending)
+			h(users.WithID(t.Context(), userID), responder, callbackUpdate(tc.data))
+		})
+	}
+}
+
+func TestPendingNotesLimitPerUser(t *testing.T) {
+	pending := notesaving.NewPendingNotes(1)
+
+	// notes of another user don't evict the first one
+	savePendingNote(users.WithID(t.Context(), 1), t, pending)
+	savePendingNote(users.WithID(t.Context(), 2), t, pending)
+
+	moved := savedNote
+	moved.Category = "home"
+
+	recategorizer := rcmocks.NewNoteRecategorizer(t)
+	recategorizer.EXPECT().Recategorize(mock.Anything, savedNote, domain.Category("home")).Return(moved, nil).Once()
+
+	responder := mocks.NewCallbackResponder(t)
+	responder.EXPECT().EditMessageText(mock.Anything, mock.Anything).Return(nil, nil).Once()
+	responder.EXPECT().AnswerCallbackQuery(mock.Anything, mock.Anything).Return(true, nil).Once()
+
+	h := notesaving.NewCategoryCallback(slog.New(log.NewDiscardHandler()), recategorizer, pending)
+	h(users.WithID(t.Context(), 1), responder, callbackUpdate("category:1:1"))
+}
@@ -0,0 +1,180 @@
+package notesaving
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// entitiesToMarkdown converts text with Telegram message entities into CommonMark.
+// Entity offsets and lengths are measured in UTF-16 code units.
+// Text outside entities is kept as is, so Markdown typed by user remains Markdown.
+func entitiesToMarkdown(text string, entities []models.MessageEntity) string {
+	if len(entities) == 0 {
+		return text
+	}
+
+	units := utf16.Encode([]rune(text))
+
+	// outer entities go first, so nested ones follow their parent
+	sorted := slices.Clone(entities)
+	slices.SortStableFunc(sorted, func(a, b models.MessageEntity) int {
+		return cmp.Or(cmp.Compare(a.Offset, b.Offset), cmp.Compare(b.Length, a.Length))
+	})
+
+	return renderRange(units, sorted, 0, len(units))
+}
+
+// renderRange renders text between start and end, entities should be sorted and lie inside the range.
+func renderRange(units []uint16, entities []models.MessageEntity, start, end int) string {
+	var b strings.Builder
+
+	pos := start
+	for i := 0; i < len(entities); {
+		entity := entities[i]
+
+		entityStart := min(max(entity.Offset, pos), end)
+		entityEnd := min(max(entity.Offset+entity.Length, entityStart), end)
+
+		// entities starting inside the current one are nested,
+		// partially overlapping ones are cut at its end
+		j := i + 1
+		for j < len(entities) && entities[j].Offset < entityEnd {
+			j++
+		}
+
+		b.WriteString(decode(units[pos:entityStart]))
+		b.WriteString(renderEntity(units, entity, entities[i+1:j], entityStart, entityEnd))
+
+		pos = entityEnd
+		i = j
+	}
+
+	b.WriteString(decode(units[pos:end]))
+
+	return b.String()
+}
+
+func renderEntity(units []uint16, entity models.MessageEntity, nested []models.MessageEntity, start, end int) string {
+	if start == end {
+		return ""
+	}
+
+	switch entity.Type {
+	case models.MessageEntityTypeCode:
+		return inlineCode(decode(units[start:end]))
+	case models.MessageEntityTypePre:
+		return codeBlock(units, entity.Language, start, end)
+	}
+
+	inner := renderRange(units, nested, start, end)
+
+	switch entity.Type {
+	case models.MessageEntityTypeBold:
+		return wrap(inner, "**")
+	case models.MessageEntityTypeItalic:
+		return wrap(inner, "*")
+	case models.MessageEntityTypeStrikethrough:
+		return wrap(inner, "~~")
+	case models.MessageEntityTypeTextLink:
+		return fmt.Sprintf("[%s](<%s>)", inner, entity.URL)
+	case models.MessageEntityTypeTextMention:
+		if entity.User == nil {
+			return inner
+		}
+
+		return fmt.Sprintf("[%s](tg://user?id=%d)", inner, entity.User.ID)
+	case models.MessageEntityTypeBlockquote, models.MessageEntityTypeExpandableBlockquote:
+		return "> " + strings.ReplaceAll(inner, "\n", "\n> ")
+	default:
+		// underline and spoiler have no CommonMark equivalent,
+		// URLs, mentions and hashtags are fine as plain text
+		return inner
+	}
+}
+
+// wrap surrounds text with emphasis marker. Surrounding spaces are kept
+// outside, because CommonMark doesn't allow them inside emphasis.
+func wrap(text, marker string) string {
+	core := strings.TrimFunc(text, unicode.IsSpace)
+	if core == "" {
+		return text
+	}
+
+	i := strings.Index(text, core)
+
+	return text[:i] + marker + core + marker + text[i+len(core):]
+}
+
+// inlineCode surrounds code with backticks, which don't occur in it.
+func inlineCode(code string) string {
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+
+	return fence + code + fence
+}
+
+// codeBlock renders fenced code block placed on its own lines.
+func codeBlock(units []uint16, language string, start, end int) string {
+	code := strings.TrimSuffix(decode(units[start:end]), "\n")
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	var b strings.Builder
+
+	if start > 0 && units[start-1] != '\n' {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fence + language + "\n" + code + "\n" + fence)
+
+	if end < len(units) && units[end] != '\n' {
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// hashtags returns unique hashtags of the text without leading '#'.
+func hashtags(text string, entities []models.MessageEntity) []string {
+	var units []uint16
+	var tags []string
+
+	for _, entity := range entities {
+		if entity.Type != models.MessageEntityTypeHashtag {
+			continue
+		}
+
+		if units == nil {
+			units = utf16.Encode([]rune(text))
+		}
+
+		start := min(max(entity.Offset, 0), len(units))
+		end := min(max(entity.Offset+entity.Length, start), len(units))
+
+		tag := strings.TrimPrefix(decode(units[start:end]), "#")
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func decode(units []uint16) string {
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,128 @@
+package notesaving_test
+
+import (
+	"log/slog"
+	"testing"
+
+	appmodels "protomorphine/tg-notes/internal/app/models"
+	ucmocks "protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
+	"protomorphine/tg-notes/internal/bot/handlers/notesaving"
+	"protomorphine/tg-notes/internal/bot/handlers/notesaving/mocks"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEntitiesToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  *models.Message
+		expected string
+	}{
+		{
+			name:     "no entities",
+			message:  &models.Message{Text: "plain *text*"},
+			expected: "plain *text*",
+		},
+		{
+			name: "bold and italic",
+			message: &models.Message{
+				Text: "bold italic",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeBold, Offset: 0, Length: 4},
+					{Type: models.MessageEntityTypeItalic, Offset: 5, Length: 6},
+				},
+			},
+			expected: "**bold** *italic*",
+		},
+		{
+			name: "surrogate pairs before entity",
+			message: &models.Message{
+				Text: "😀😀 bold",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeBold, Offset: 5, Length: 4},
+				},
+			},
+			expected: "😀😀 **bold**",
+		},
+		{
+			name: "spaces are moved out of emphasis",
+			message: &models.Message{
+				Text: "a bold b",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeBold, Offset: 1, Length: 6},
+				},
+			},
+			expected: "a **bold** b",
+		},
+		{
+			name: "nested entities",
+			message: &models.Message{
+				Text: "see docs here",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeTextLink, Offset: 4, Length: 9, URL: "https://example.com"},
+					{Type: models.MessageEntityTypeBold, Offset: 4, Length: 4},
+				},
+			},
+			expected: "see [**docs** here](<https://example.com>)",
+		},
+		{
+			name: "inline code with backtick",
+			message: &models.Message{
+				Text: "run a`b",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeCode, Offset: 4, Length: 3},
+				},
+			},
+			expected: "run ``a`b``",
+		},
+		{
+			name: "code block",
+			message: &models.Message{
+				Text: "snippet: fmt.Println(\"*\")\ndone",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypePre, Offset: 9, Length: 17, Language: "go"},
+					{Type: models.MessageEntityTypeBold, Offset: 22, Length: 1},
+				},
+			},
+			expected: "snippet: \n```go\nfmt.Println(\"*\")\n```\ndone",
+		},
+		{
+			name: "text mention and blockquote",
+			message: &models.Message{
+				Text: "ask Bob\nquote\nlines",
+				Entities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeTextMention, Offset: 4, Length: 3, User: &models.User{ID: 42}},
+					{Type: models.MessageEntityTypeBlockquote, Offset: 8, Length: 11},
+				},
+			},
+			expected: "ask [Bob](tg://user?id=42)\n> quote\n> lines",
+		},
+		{
+			name: "caption entities",
+			message: &models.Message{
+				Caption: "gone",
+				CaptionEntities: []models.MessageEntity{
+					{Type: models.MessageEntityTypeStrikethrough, Offset: 0, Length: 4},
+				},
+			},
+			expected: "~~gone~~",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			saver := ucmocks.NewNoteSaver(t)
+			saver.EXPECT().Save(mock.Anything, appmodels.NoteInput{Text: tc.expected}).Return(appmodels.SaveResult{}, nil).Once()
+
+			api := mocks.NewBotAPI(t)
+			api.EXPECT().SendMessage(mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+			h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, notesaving.NewPendingNotes(10), attachmentsCfg)
+			h(t.Context(), api, &models.Update{Message: tc.message})
+		})
+	}
+}
@@ -5,12 +5,19 @@ import (
 	"bytes"
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path"
+	"strings"
 	"text/template"
 
+	appmodels "protomorphine/tg-notes/internal/app/models"
 	"protomorphine/tg-notes/internal/app/usecases/notesaving"
+	"protomorphine/tg-notes/internal/app/users"
 	"protomorphine/tg-notes/internal/bot/middleware"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
 	"protomorphine/tg-notes/internal/log"
 
 	"github.com/go-telegram/bot"
@@ -20,7 +27,11 @@ import (
 const (
 	successTemplate  = "resources/save_success.tmpl"
 	errorTemplate    = "resources/save_err.tmpl"
+	existsTemplate   = "resources/save_exists.tmpl"
 	emptyMsgTemplate = "resources/empty_message.tmpl"
+
+	categoryExpiredTemplate = "resources/category_expired.tmpl"
+	categoryErrTemplate     = "resources/category_err.tmpl"
 )
 
 var (
@@ -33,17 +44,31 @@ var (
 func init() {
 	templates = make(map[string]*template.Template)
 
-	if tmpl, err := template.ParseFS(templatesFS, successTemplate); err != nil {
+	funcs := template.FuncMap{"escape": escapeMarkdown}
+
+	if tmpl, err := template.New(path.Base(successTemplate)).Funcs(funcs).ParseFS(templatesFS, successTemplate); err == nil {
 		templates[successTemplate] = tmpl
 	}
 
-	if tmpl, err := template.ParseFS(templatesFS, errorTemplate); err != nil {
+	if tmpl, err := template.ParseFS(templatesFS, errorTemplate); err == nil {
 		templates[errorTemplate] = tmpl
 	}
 
-	if tmpl, err := template.ParseFS(templatesFS, emptyMsgTemplate); err != nil {
+	if tmpl, err := template.ParseFS(templatesFS, existsTemplate); err == nil {
+		templates[existsTemplate] = tmpl
+	}
+
+	if tmpl, err := template.ParseFS(templatesFS, emptyMsgTemplate); err == nil {
 		templates[emptyMsgTemplate] = tmpl
 	}
+
+	if tmpl, err := template.ParseFS(templatesFS, categoryExpiredTemplate); err == nil {
+		templates[categoryExpiredTemplate] = tmpl
+	}
+
+	if tmpl, err := template.ParseFS(templatesFS, categoryErrTemplate); err == nil {
+		templates[categoryErrTemplate] = tmpl
+	}
 }
 
 // MessageSender is an interface for sending messages.
@@ -53,12 +78,28 @@ type MessageSender interface {
 	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
 }
 
+// BotAPI is an interface for sending messages and downloading files sent to the bot.
+//
+//mockery:generate: true
+type BotAPI interface {
+	MessageSender
+	FileGetter
+}
+
 // Handler represents the notesaving handler for the bot.
-type Handler func(ctx context.Context, sender MessageSender, update *models.Update)
+type Handler func(ctx context.Context, api BotAPI, update *models.Update)
 
-// New creates a new notesaving Handler.
-func New(logger *slog.Logger, saver notesaving.NoteSaver) Handler {
-	return func(ctx context.Context, sender MessageSender, update *models.Update) {
+// saveSuccess represents arguments of the success template.
+type saveSuccess struct {
+	appmodels.SaveResult
+	Skipped []string // names of attachments, which weren't saved
+}
+
+// New creates a new notesaving Handler. Saved notes are put to pending,
+// so user can change their category via inline keyboard.
+// Photo, document and voice attached to the message are saved along with the note.
+func New(logger *slog.Logger, saver notesaving.NoteSaver, pending *PendingNotes, cfg *config.AttachmentsConfig) Handler {
+	return func(ctx context.Context, api BotAPI, update *models.Update) {
 		const op = "bot.handlers.add"
 		logger := logger.With(log.Op(op), log.ReqID(middleware.GetReqID(ctx)))
 
@@ -70,13 +111,28 @@ func New(logger *slog.Logger, saver notesaving.NoteSaver) Handler {
 		messageID := update.Message.ID
 		chatID := update.Message.Chat.ID
 
-		text := extractNoteText(update.Message)
+		input := appmodels.NoteInput{
+			Text: extractNoteText(update.Message),
+			Meta: noteMetadata(update.Message),
+		}
+		var skipped []string
+
+		for _, ref := range messageAttachments(update.Message) {
+			attachment, err := downloadAttachment(ctx, api, cfg, ref)
+			if err != nil {
+				logger.Warn("attachment skipped", slog.String("name", ref.name), log.Err(err))
+				skipped = append(skipped, ref.name)
+				continue
+			}
+
+			input.Attachments = append(input.Attachments, attachment)
+		}
 
-		if text == "" {
+		if input.Text == "" && len(input.Attachments) == 0 {
 			logger.Warn("received message with empty text and caption")
 
 			if message, err := render(emptyMsgTemplate, struct{}{}); err == nil {
-				sendMessage(ctx, logger, sender, chatID, messageID, message)
+				sendMessage(ctx, logger, api, chatID, messageID, message, nil)
 			} else {
 				logger.Error("error while rendering template", log.Err(err))
 			}
@@ -84,12 +140,17 @@ func New(logger *slog.Logger, saver notesaving.NoteSaver) Handler {
 			return
 		}
 
-		res, err := saver.Save(ctx, text)
+		res, err := saver.Save(ctx, input)
 		if err != nil {
 			logger.Error("error occured while saving new note", log.Err(err))
 
-			if message, err := render(errorTemplate, struct{}{}); err == nil {
-				sendMessage(ctx, logger, sender, chatID, messageID, message)
+			templatePath := errorTemplate
+			if errors.Is(err, domain.ErrNoteExists) {
+				templatePath = existsTemplate
+			}
+
+			if message, err := render(templatePath, struct{}{}); err == nil {
+				sendMessage(ctx, logger, api, chatID, messageID, message, nil)
 			} else {
 				logger.Error("error while rendering template", log.Err(err))
 			}
@@ -99,8 +160,19 @@ func New(logger *slog.Logger, saver notesaving.NoteSaver) Handler {
 
 		logger.Info("new note saved")
 
-		if message, err := render(successTemplate, res); err == nil {
-			sendMessage(ctx, logger, sender, chatID, messageID, message)
+		var keyboard models.ReplyMarkup
+
+		// the appended note has content saved earlier, so it isn't moved and learned as the new one
+		if res.Outcome != domain.SaveAppended {
+			// the user is unknown only in tests, auth middleware sets it
+			owner, _ := users.ID(ctx)
+
+			id := pending.put(owner, res)
+			keyboard = categoryKeyboard(id, pendingNote{note: res, options: res.Candidates}, true)
+		}
+
+		if message, err := render(successTemplate, saveSuccess{SaveResult: res, Skipped: skipped}); err == nil {
+			sendMessage(ctx, logger, api, chatID, messageID, message, keyboard)
 		} else {
 			logger.Error("error while rendering template", log.Err(err))
 		}
@@ -114,6 +186,7 @@ func sendMessage(
 	chatID int64,
 	replyID int,
 	text string,
+	replyMarkup models.ReplyMarkup,
 ) {
 	_, err := sender.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: chatID,
@@ -121,21 +194,65 @@ func sendMessage(
 		ReplyParameters: &models.ReplyParameters{
 			MessageID: replyID,
 		},
-		ParseMode: models.ParseModeMarkdownV1,
+		ParseMode:   models.ParseModeMarkdownV1,
+		ReplyMarkup: replyMarkup,
 	})
 	if err != nil {
 		logger.Error("error occured while sending message", log.Err(err))
 	}
 }
 
+// extractNoteText returns message text or caption with formatting converted to Markdown.
 func extractNoteText(message *models.Message) string {
-	text := message.Text
+	if message.Text != "" {
+		return entitiesToMarkdown(message.Text, message.Entities)
+	}
 
+	return entitiesToMarkdown(message.Caption, message.CaptionEntities)
+}
+
+// noteMetadata returns source of the message and its hashtags.
+func noteMetadata(message *models.Message) domain.Metadata {
+	text, entities := message.Text, message.Entities
 	if text == "" {
-		text = message.Caption
+		text, entities = message.Caption, message.CaptionEntities
 	}
 
-	return text
+	return domain.Metadata{
+		ChatID:        message.Chat.ID,
+		MessageID:     message.ID,
+		ForwardedFrom: forwardOrigin(message.ForwardOrigin),
+		Tags:          hashtags(text, entities),
+	}
+}
+
+// forwardOrigin returns name of the original message author.
+func forwardOrigin(origin *models.MessageOrigin) string {
+	if origin == nil {
+		return ""
+	}
+
+	switch origin.Type {
+	case models.MessageOriginTypeUser:
+		user := origin.MessageOriginUser.SenderUser
+		return strings.TrimSpace(user.FirstName + " " + user.LastName)
+	case models.MessageOriginTypeHiddenUser:
+		return origin.MessageOriginHiddenUser.SenderUserName
+	case models.MessageOriginTypeChat:
+		return origin.MessageOriginChat.SenderChat.Title
+	case models.MessageOriginTypeChannel:
+		return origin.MessageOriginChannel.Chat.Title
+	}
+
+	return ""
+}
+
+// markdownEscaper escapes characters, which are special in Markdown (legacy) parse mode.
+var markdownEscaper = strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`)
+
+// escapeMarkdown escapes text typed by user, so it's shown as is in messages sent with ParseModeMarkdownV1.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
 }
 
 func render(templatePath string, args any) (string, error) {
@@ -1,28 +1,41 @@
 package notesaving_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	appmodels "protomorphine/tg-notes/internal/app/models"
 	ucmocks "protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
 	"protomorphine/tg-notes/internal/bot/handlers/notesaving"
 	"protomorphine/tg-notes/internal/bot/handlers/notesaving/mocks"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
 	"protomorphine/tg-notes/internal/log"
 
+	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+var attachmentsCfg = &config.AttachmentsConfig{
+	MaxSize:      1024,
+	AllowedTypes: []string{"image/*", "application/pdf"},
+}
+
 func TestNilMessage(t *testing.T) {
 	update := &models.Update{Message: nil}
 
 	saver := ucmocks.NewNoteSaver(t)
-	sender := mocks.NewMessageSender(t)
+	sender := mocks.NewBotAPI(t)
 
 	logger := slog.New(log.NewDiscardHandler())
-	h := notesaving.New(logger, saver)
+	h := notesaving.New(logger, saver, notesaving.NewPendingNotes(10), attachmentsCfg)
 
 	h(t.Context(), sender, update)
 
@@ -39,13 +52,13 @@ func TestEmptyMessageText(t *testing.T) {
 	}
 
 	saver := ucmocks.NewNoteSaver(t)
-	sender := mocks.NewMessageSender(t)
+	sender := mocks.NewBotAPI(t)
 
-	saver.EXPECT().Save(mock.Anything, mock.AnythingOfType("string")).Return(appmodels.SaveResult{}, nil)
+	saver.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(appmodels.SaveResult{}, nil)
 	sender.EXPECT().SendMessage(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 
 	logger := slog.New(log.NewDiscardHandler())
-	h := notesaving.New(logger, saver)
+	h := notesaving.New(logger, saver, notesaving.NewPendingNotes(10), attachmentsCfg)
 
 	h(t.Context(), sender, update)
 }
@@ -59,12 +72,12 @@ func TestTextAndCaptionEmpty(t *testing.T) {
 	}
 
 	saver := ucmocks.NewNoteSaver(t)
-	sender := mocks.NewMessageSender(t)
+	sender := mocks.NewBotAPI(t)
 
 	sender.EXPECT().SendMessage(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 
 	logger := slog.New(log.NewDiscardHandler())
-	h := notesaving.New(logger, saver)
+	h := notesaving.New(logger, saver, notesaving.NewPendingNotes(10), attachmentsCfg)
 
 	h(t.Context(), sender, update)
 
@@ -86,7 +99,7 @@ func TestAddNote(t *testing.T) {
 				},
 			},
 			setupSaver: func(adder *ucmocks.NoteSaver) {
-				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("string")).Return(appmodels.SaveResult{}, nil)
+				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(appmodels.SaveResult{}, nil)
 			},
 		},
 		{
@@ -98,7 +111,7 @@ func TestAddNote(t *testing.T) {
 				},
 			},
 			setupSaver: func(adder *ucmocks.NoteSaver) {
-				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("string")).Return(appmodels.SaveResult{}, nil)
+				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(appmodels.SaveResult{}, nil)
 			},
 		},
 		{
@@ -109,7 +122,7 @@ func TestAddNote(t *testing.T) {
 				},
 			},
 			setupSaver: func(adder *ucmocks.NoteSaver) {
-				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("string")).Return(appmodels.SaveResult{}, errors.New("internal adder error"))
+				adder.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(appmodels.SaveResult{}, errors.New("internal adder error"))
 			},
 		},
 	}
@@ -121,14 +134,271 @@ func TestAddNote(t *testing.T) {
 			saver := ucmocks.NewNoteSaver(t)
 			tc.setupSaver(saver)
 
-			sender := mocks.NewMessageSender(t)
+			sender := mocks.NewBotAPI(t)
 
 			sender.EXPECT().SendMessage(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 
 			logger := slog.New(log.NewDiscardHandler())
-			h := notesaving.New(logger, saver)
+			h := notesaving.New(logger, saver, notesaving.NewPendingNotes(10), attachmentsCfg)
 
 			h(t.Context(), sender, tc.update)
 		})
 	}
 }
+
+func TestAttachments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("file content"))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name        string
+		message     *models.Message
+		setupAPI    func(*mocks.BotAPI)
+		attachments []domain.Attachment
+		skipped     string
+	}{
+		{
+			name: "photo without text",
+			message: &models.Message{
+				Photo: []models.PhotoSize{
+					{FileID: "small", FileUniqueID: "s", FileSize: 10},
+					{FileID: "large", FileUniqueID: "l", FileSize: 100},
+				},
+			},
+			setupAPI: func(api *mocks.BotAPI) {
+				file := &models.File{FileID: "large", FilePath: "photos/large.jpg"}
+				api.EXPECT().GetFile(mock.Anything, &bot.GetFileParams{FileID: "large"}).Return(file, nil).Once()
+				api.EXPECT().FileDownloadLink(file).Return(server.URL).Once()
+			},
+			attachments: []domain.Attachment{{Name: "photo_l.jpg", Data: []byte("file content")}},
+		},
+		{
+			name: "document name is sanitized",
+			message: &models.Message{
+				Caption:  "caption",
+				Document: &models.Document{FileID: "doc", FileName: "../../secret.pdf", MimeType: "application/pdf"},
+			},
+			setupAPI: func(api *mocks.BotAPI) {
+				file := &models.File{FileID: "doc"}
+				api.EXPECT().GetFile(mock.Anything, &bot.GetFileParams{FileID: "doc"}).Return(file, nil).Once()
+				api.EXPECT().FileDownloadLink(file).Return(server.URL).Once()
+			},
+			attachments: []domain.Attachment{{Name: "secret.pdf", Data: []byte("file content")}},
+		},
+		{
+			name: "parent directory name is replaced",
+			message: &models.Message{
+				Caption:  "caption",
+				Document: &models.Document{FileID: "doc", FileUniqueID: "d", FileName: "notes/..", MimeType: "application/pdf"},
+			},
+			setupAPI: func(api *mocks.BotAPI) {
+				file := &models.File{FileID: "doc"}
+				api.EXPECT().GetFile(mock.Anything, &bot.GetFileParams{FileID: "doc"}).Return(file, nil).Once()
+				api.EXPECT().FileDownloadLink(file).Return(server.URL).Once()
+			},
+			attachments: []domain.Attachment{{Name: "document_d", Data: []byte("file content")}},
+		},
+		{
+			name: "hidden name is replaced",
+			message: &models.Message{
+				Caption:  "caption",
+				Document: &models.Document{FileID: "doc", FileUniqueID: "d", FileName: ".gitignore", MimeType: "application/pdf"},
+			},
+			setupAPI: func(api *mocks.BotAPI) {
+				file := &models.File{FileID: "doc"}
+				api.EXPECT().GetFile(mock.Anything, &bot.GetFileParams{FileID: "doc"}).Return(file, nil).Once()
+				api.EXPECT().FileDownloadLink(file).Return(server.URL).Once()
+			},
+			attachments: []domain.Attachment{{Name: "document_d", Data: []byte("file content")}},
+		},
+		{
+			name: "type isn't allowed",
+			message: &models.Message{
+				Text:  "text",
+				Voice: &models.Voice{FileID: "voice", FileUniqueID: "v", MimeType: "audio/ogg"},
+			},
+			setupAPI: func(*mocks.BotAPI) {},
+			skipped:  "voice_v.ogg",
+		},
+		{
+			name: "file is too big",
+			message: &models.Message{
+				Text:     "text",
+				Document: &models.Document{FileID: "doc", FileName: "big.pdf", MimeType: "application/pdf", FileSize: 2048},
+			},
+			setupAPI: func(*mocks.BotAPI) {},
+			skipped:  "big.pdf",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			saver := ucmocks.NewNoteSaver(t)
+			saver.EXPECT().
+				Save(mock.Anything, appmodels.NoteInput{Text: extractText(tc.message), Attachments: tc.attachments}).
+				Return(appmodels.SaveResult{Title: "note"}, nil).
+				Once()
+
+			api := mocks.NewBotAPI(t)
+			tc.setupAPI(api)
+			api.EXPECT().
+				SendMessage(mock.Anything, mock.AnythingOfType("*bot.SendMessageParams")).
+				Run(func(_ context.Context, params *bot.SendMessageParams) {
+					if tc.skipped != "" {
+						require.Contains(t, params.Text, "`"+tc.skipped+"`")
+					}
+				}).
+				Return(nil, nil).
+				Once()
+
+			h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, notesaving.NewPendingNotes(10), attachmentsCfg)
+			h(t.Context(), api, &models.Update{Message: tc.message})
+		})
+	}
+}
+
+func extractText(message *models.Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+
+	return message.Caption
+}
+
+func TestNoteMetadata(t *testing.T) {
+	message := &models.Message{
+		ID:   7,
+		Chat: models.Chat{ID: 42},
+		Text: "#go 😀 notes #todo #go",
+		Entities: []models.MessageEntity{
+			{Type: models.MessageEntityTypeHashtag, Offset: 0, Length: 3},
+			{Type: models.MessageEntityTypeHashtag, Offset: 13, Length: 5},
+			{Type: models.MessageEntityTypeHashtag, Offset: 19, Length: 3},
+		},
+		ForwardOrigin: &models.MessageOrigin{
+			Type:                 models.MessageOriginTypeChannel,
+			MessageOriginChannel: &models.MessageOriginChannel{Chat: models.Chat{Title: "Go news"}},
+		},
+	}
+
+	saver := ucmocks.NewNoteSaver(t)
+	saver.EXPECT().
+		Save(mock.Anything, appmodels.NoteInput{
+			Text: message.Text,
+			Meta: domain.Metadata{
+				ChatID:        42,
+				MessageID:     7,
+				ForwardedFrom: "Go news",
+				Tags:          []string{"go", "todo"},
+			},
+		}).
+		Return(appmodels.SaveResult{}, nil).
+		Once()
+
+	api := mocks.NewBotAPI(t)
+	api.EXPECT().SendMessage(mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, notesaving.NewPendingNotes(10), attachmentsCfg)
+	h(t.Context(), api, &models.Update{Message: message})
+}
+
+func TestSaveReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      appmodels.SaveResult
+		err      error
+		expected string
+	}{
+		{
+			name:     "renamed note path",
+			res:      appmodels.SaveResult{Title: "note", Path: "dev/note-2.md", Outcome: domain.SaveRenamed},
+			expected: "*Path*: `dev/note-2.md` (the name was taken)",
+		},
+		{
+			name:     "appended note",
+			res:      appmodels.SaveResult{Title: "note", Path: "dev/note.md", Outcome: domain.SaveAppended},
+			expected: "appended to the existing one",
+		},
+		{
+			name:     "title with markdown characters",
+			res:      appmodels.SaveResult{Title: "snake_case [draft", Category: "dev_ops", Outcome: domain.SaveCreated},
+			expected: "*Title*: snake\\_case \\[draft\n*Category*: dev\\_ops",
+		},
+		{
+			name:     "rejected note",
+			err:      fmt.Errorf("save: %w", domain.ErrNoteExists),
+			expected: "already exists",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			saver := ucmocks.NewNoteSaver(t)
+			saver.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(tc.res, tc.err).Once()
+
+			api := mocks.NewBotAPI(t)
+			api.EXPECT().
+				SendMessage(mock.Anything, mock.AnythingOfType("*bot.SendMessageParams")).
+				Run(func(_ context.Context, params *bot.SendMessageParams) {
+					require.Contains(t, params.Text, tc.expected)
+				}).
+				Return(nil, nil).
+				Once()
+
+			h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, notesaving.NewPendingNotes(10), attachmentsCfg)
+			h(t.Context(), api, &models.Update{Message: &models.Message{Text: "text"}})
+		})
+	}
+}
+
+func TestCategoryKeyboard(t *testing.T) {
+	tests := []struct {
+		name             string
+		outcome          domain.SaveOutcome
+		expectedKeyboard bool
+	}{
+		{name: "created note", outcome: domain.SaveCreated, expectedKeyboard: true},
+		{name: "renamed note", outcome: domain.SaveRenamed, expectedKeyboard: true},
+		{name: "appended note", outcome: domain.SaveAppended, expectedKeyboard: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := appmodels.SaveResult{
+				Title:      "note",
+				Name:       "note",
+				Category:   "dev",
+				Outcome:    tc.outcome,
+				Candidates: []domain.Category{"dev", "go"},
+			}
+
+			saver := ucmocks.NewNoteSaver(t)
+			saver.EXPECT().Save(mock.Anything, mock.AnythingOfType("models.NoteInput")).Return(res, nil).Once()
+
+			api := mocks.NewBotAPI(t)
+			api.EXPECT().
+				SendMessage(mock.Anything, mock.AnythingOfType("*bot.SendMessageParams")).
+				Run(func(_ context.Context, params *bot.SendMessageParams) {
+					if !tc.expectedKeyboard {
+						require.Nil(t, params.ReplyMarkup)
+						return
+					}
+
+					require.IsType(t, &models.InlineKeyboardMarkup{}, params.ReplyMarkup)
+				}).
+				Return(nil, nil).
+				Once()
+
+			h := notesaving.New(slog.New(log.NewDiscardHandler()), saver, notesaving.NewPendingNotes(10), attachmentsCfg)
+			h(t.Context(), api, &models.Update{Message: &models.Message{Text: "text"}})
+		})
+	}
+}
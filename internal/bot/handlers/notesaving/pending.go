@@ -0,0 +1,132 @@
+package notesaving
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+
+	appmodels "protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// CategoryCallbackPrefix is the prefix of callback data sent by category keyboard buttons.
+	CategoryCallbackPrefix = "category:"
+
+	otherOption = "other"
+
+	// maxKeyboardButtons is a maximum count of inline keyboard buttons allowed by Telegram.
+	maxKeyboardButtons = 100
+)
+
+type pendingNote struct {
+	owner   int64 // ID of the user, who saved the note
+	note    appmodels.SaveResult
+	options []domain.Category
+}
+
+// PendingNotes stores recently saved notes, which category can be changed via inline keyboard.
+// Only the last limit notes of every user are kept. It is safe for concurrent use.
+type PendingNotes struct {
+	mu    sync.Mutex
+	seq   uint64
+	limit int
+	order map[int64][]string // IDs of notes of every user in order of saving
+	notes map[string]pendingNote
+}
+
+// NewPendingNotes creates a new PendingNotes.
+func NewPendingNotes(limit int) *PendingNotes {
+	return &PendingNotes{
+		limit: limit,
+		order: make(map[int64][]string),
+		notes: make(map[string]pendingNote),
+	}
+}
+
+// put stores a note of the owner and returns its short ID, which fits into callback data.
+func (p *PendingNotes) put(owner int64, note appmodels.SaveResult) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	id := strconv.FormatUint(p.seq, 36)
+
+	p.notes[id] = pendingNote{owner: owner, note: note, options: note.Candidates}
+
+	order := append(p.order[owner], id)
+	for len(order) > p.limit {
+		delete(p.notes, order[0])
+		order = order[1:]
+	}
+	p.order[owner] = order
+
+	return id
+}
+
+// get returns the note with given ID, if it belongs to the owner.
+func (p *PendingNotes) get(owner int64, id string) (pendingNote, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.notes[id]
+	if !ok || pending.owner != owner {
+		return pendingNote{}, false
+	}
+
+	return pending, true
+}
+
+func (p *PendingNotes) setOptions(id string, options []domain.Category) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pending, ok := p.notes[id]; ok {
+		pending.options = options
+		p.notes[id] = pending
+	}
+}
+
+func (p *PendingNotes) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.notes[id]
+	if !ok {
+		return
+	}
+
+	delete(p.notes, id)
+	p.order[pending.owner] = slices.DeleteFunc(p.order[pending.owner], func(ordered string) bool {
+		return ordered == id
+	})
+}
+
+// categoryKeyboard builds inline keyboard with one button per category option.
+// Current note category is marked. The "other…" button is added if withOther is set.
+func categoryKeyboard(id string, pending pendingNote, withOther bool) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(pending.options)+1)
+
+	for i, category := range pending.options {
+		text := string(category)
+		if category == pending.note.Category {
+			text = "✅ " + text
+		}
+
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: CategoryCallbackPrefix + id + ":" + strconv.Itoa(i),
+		}})
+	}
+
+	if withOther {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         "other…",
+			CallbackData: CategoryCallbackPrefix + id + ":" + otherOption,
+		}})
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,119 @@
+// Package search contains handler for /search command
+package search
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"protomorphine/tg-notes/internal/app/usecases/search"
+	"protomorphine/tg-notes/internal/bot/middleware"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Cmd is the command string for the search handler.
+const Cmd = "search"
+
+const (
+	resultTemplate     = "search_result.tmpl"
+	notFoundTemplate   = "not_found.tmpl"
+	emptyQueryTemplate = "empty_query.tmpl"
+)
+
+var (
+	//go:embed resources
+	templatesFS embed.FS
+
+	templates = template.Must(
+		template.New("").
+			Funcs(template.FuncMap{
+				"escape": bot.EscapeMarkdown,
+				"inc":    func(i int) int { return i + 1 },
+			}).
+			ParseFS(templatesFS, "resources/*.tmpl"),
+	)
+)
+
+// MessageSender is an interface for sending messages.
+//
+//mockery:generate: true
+type MessageSender interface {
+	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
+}
+
+// Handler represents the search handler for the bot.
+type Handler func(ctx context.Context, sender MessageSender, update *models.Update)
+
+// New creates a new search Handler.
+func New(logger *slog.Logger, searcher search.NoteSearcher) Handler {
+	return func(ctx context.Context, sender MessageSender, update *models.Update) {
+		const op = "bot.handlers.search"
+		logger := logger.With(log.Op(op), log.ReqID(middleware.GetReqID(ctx)))
+
+		if update.Message == nil {
+			logger.Warn("nil message received")
+			return
+		}
+
+		query := extractQuery(update.Message.Text)
+
+		var (
+			tmpl string
+			args any
+		)
+
+		if query == "" {
+			tmpl = emptyQueryTemplate
+		} else if results := searcher.Search(ctx, query); len(results) == 0 {
+			tmpl = notFoundTemplate
+		} else {
+			tmpl, args = resultTemplate, results
+			logger.Info("notes found", slog.Int("count", len(results)))
+		}
+
+		text, err := render(tmpl, args)
+		if err != nil {
+			logger.Error("error while rendering template", log.Err(err))
+			return
+		}
+
+		_, err = sender.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   text,
+			ReplyParameters: &models.ReplyParameters{
+				MessageID: update.Message.ID,
+			},
+			ParseMode: models.ParseModeMarkdown,
+		})
+		if err != nil {
+			logger.Error("error occured while sending message", log.Err(err))
+		}
+	}
+}
+
+// extractQuery returns text after the command, i.e. "go generics" for "/search go generics".
+func extractQuery(text string) string {
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(text[i:])
+}
+
+func render(name string, args any) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := templates.ExecuteTemplate(buf, name, args); err != nil {
+		return "", fmt.Errorf("render %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,75 @@
+package search_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	appmodels "protomorphine/tg-notes/internal/app/models"
+	ucmocks "protomorphine/tg-notes/internal/app/usecases/search/mocks"
+	"protomorphine/tg-notes/internal/bot/handlers/search"
+	"protomorphine/tg-notes/internal/bot/handlers/search/mocks"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		setupSearcher func(*ucmocks.NoteSearcher)
+		expectedText  string
+	}{
+		{
+			name:          "empty query",
+			text:          "/search",
+			setupSearcher: func(*ucmocks.NoteSearcher) {},
+			expectedText:  "Send a query",
+		},
+		{
+			name: "nothing found",
+			text: "/search golang",
+			setupSearcher: func(m *ucmocks.NoteSearcher) {
+				m.EXPECT().Search(mock.Anything, "golang").Return(nil).Once()
+			},
+			expectedText: "Nothing found",
+		},
+		{
+			name: "notes found",
+			text: "/search  golang generics ",
+			setupSearcher: func(m *ucmocks.NoteSearcher) {
+				m.EXPECT().Search(mock.Anything, "golang generics").Return([]appmodels.SearchResult{
+					{Title: "generics", Category: "dev", Snippet: "type params (go 1.18)"},
+				}).Once()
+			},
+			expectedText: "type params \\(go 1\\.18\\)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			searcher := ucmocks.NewNoteSearcher(t)
+			tc.setupSearcher(searcher)
+
+			sender := mocks.NewMessageSender(t)
+			sender.EXPECT().
+				SendMessage(mock.Anything, mock.AnythingOfType("*bot.SendMessageParams")).
+				Run(func(_ context.Context, params *bot.SendMessageParams) {
+					require.Contains(t, params.Text, tc.expectedText)
+				}).
+				Return(nil, nil).
+				Once()
+
+			logger := slog.New(log.NewDiscardHandler())
+			h := search.New(logger, searcher)
+
+			h(t.Context(), sender, &models.Update{Message: &models.Message{Text: tc.text}})
+		})
+	}
+}
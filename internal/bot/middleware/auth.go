@@ -5,7 +5,7 @@ import (
 	_ "embed"
 	"log/slog"
 
-	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/app/users"
 	"protomorphine/tg-notes/internal/log"
 
 	"github.com/go-telegram/bot"
@@ -15,36 +15,53 @@ import (
 //go:embed resources/auth_err.tmpl
 var authErrMsg string
 
+// UserChecker is an interface to check if user is allowed to use the bot.
+type UserChecker interface {
+	Has(id int64) bool
+}
+
 // NewAuth function creates middleware to authorize user requests by telegram ID.
-func NewAuth(logger *slog.Logger, cfg *config.BotConfig) bot.Middleware {
+// ID of authorized user is stored in request context, see users.ID.
+func NewAuth(logger *slog.Logger, checker UserChecker) bot.Middleware {
 	return func(next bot.HandlerFunc) bot.HandlerFunc {
 		logger := logger.With(slog.String("component", "middleware/auth"))
 
 		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
 			logger := logger.With(slog.String("reqID", GetReqID(ctx).String()))
 
-			if update.Message == nil {
-				logger.Warn("nil message received")
+			user, chatID := updateSource(update)
+			if user == nil {
+				logger.Warn("update without sender received")
 				return
 			}
 
-			if update.Message.From.ID == cfg.AllowedUserID {
+			if checker.Has(user.ID) {
 				logger.Info("successfully authorized new request")
 
-				next(ctx, b, update)
+				next(users.WithID(ctx, user.ID), b, update)
 				return
 			}
 
-			logger.Warn("sender ID missmatch allowed user ID", slog.Int64("fromID", update.Message.From.ID))
+			logger.Warn("sender ID isn't allowed", slog.Int64("fromID", user.ID))
 
-			_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID:    update.Message.Chat.ID,
-				Text:      authErrMsg,
-				ParseMode: models.ParseModeMarkdown,
-				ReplyParameters: &models.ReplyParameters{
-					MessageID: update.Message.ID,
-				},
-			})
+			var err error
+
+			if update.CallbackQuery != nil {
+				_, err = b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+					CallbackQueryID: update.CallbackQuery.ID,
+					Text:            authErrMsg,
+					ShowAlert:       true,
+				})
+			} else {
+				_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:    chatID,
+					Text:      authErrMsg,
+					ParseMode: models.ParseModeMarkdown,
+					ReplyParameters: &models.ReplyParameters{
+						MessageID: update.Message.ID,
+					},
+				})
+			}
 			if err != nil {
 				logger.Error("error while sending message", log.Err(err))
 			}
@@ -9,15 +9,27 @@ import (
 	"github.com/go-telegram/bot/models"
 )
 
-// NewLog creates middleware for log incoming requests.
-func NewLog(logger *slog.Logger) bot.Middleware {
+// HandlerObserver records duration of handled requests, e.g. to export metrics.
+type HandlerObserver interface {
+	ObserveHandler(duration time.Duration)
+}
+
+// NewLog creates middleware for log incoming requests and observe their duration.
+func NewLog(logger *slog.Logger, observer HandlerObserver) bot.Middleware {
 	return func(next bot.HandlerFunc) bot.HandlerFunc {
 		logger := logger.With(slog.String("component", "middleware/log"))
 
 		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			var username string
+
+			user, chatID := updateSource(update)
+			if user != nil {
+				username = user.Username
+			}
+
 			logger := logger.With(
-				slog.String("username", update.Message.From.Username),
-				slog.Int64("chatID", update.Message.Chat.ID),
+				slog.String("username", username),
+				slog.Int64("chatID", chatID),
 				slog.String("reqID", GetReqID(ctx).String()),
 			)
 
@@ -26,7 +38,10 @@ func NewLog(logger *slog.Logger) bot.Middleware {
 
 			next(ctx, b, update)
 
-			logger.Info("request completed", slog.String("duration", time.Since(t1).String()))
+			duration := time.Since(t1)
+			observer.ObserveHandler(duration)
+
+			logger.Info("request completed", slog.String("duration", duration.String()))
 		}
 	}
 }
@@ -1,2 +1,23 @@
 // Package middleware provides middleware for telegram bot
 package middleware
+
+import "github.com/go-telegram/bot/models"
+
+// updateSource returns the user who sent the update and the chat where it was sent.
+// User is nil if the update has no sender, e.g. channel post.
+func updateSource(update *models.Update) (*models.User, int64) {
+	switch {
+	case update.Message != nil:
+		return update.Message.From, update.Message.Chat.ID
+
+	case update.CallbackQuery != nil:
+		var chatID int64
+		if message := update.CallbackQuery.Message.Message; message != nil {
+			chatID = message.Chat.ID
+		}
+
+		return &update.CallbackQuery.From, chatID
+	}
+
+	return nil, 0
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// NewShutdownContext creates middleware to run handlers with ctx instead of the context
+// of update receiving. The receiving context is cancelled on shutdown to stop taking
+// new updates, while handlers in progress should be finished until ctx is done.
+func NewShutdownContext(ctx context.Context) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(_ context.Context, b *bot.Bot, update *models.Update) {
+			next(ctx, b, update)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"protomorphine/tg-notes/internal/bot/middleware"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestNewShutdownContext(t *testing.T) {
+	handlerCtx := context.WithValue(context.Background(), ctxKey{}, "handler")
+
+	receivingCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var called bool
+	next := func(ctx context.Context, _ *bot.Bot, _ *models.Update) {
+		called = true
+
+		// the handler isn't stopped, when update receiving is stopped on shutdown
+		require.NoError(t, ctx.Err())
+		require.Equal(t, "handler", ctx.Value(ctxKey{}))
+	}
+
+	h := middleware.NewShutdownContext(handlerCtx)(next)
+	h(receivingCtx, nil, &models.Update{})
+
+	require.True(t, called)
+}
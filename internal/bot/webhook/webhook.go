@@ -0,0 +1,62 @@
+// Package webhook provides guard of the endpoint, which receives updates from Telegram.
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+)
+
+// SecretHeader is a header, which Telegram sends the secret token in.
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// NewSecret returns a random secret token. It consists of base32 characters, which Telegram accepts.
+func NewSecret() string {
+	return rand.Text()
+}
+
+// NewGuard creates handler, which passes requests to next only if they have the secret token
+// and come from one of the networks. Requests from any address are accepted, if networks are empty.
+// Rejected requests don't reach the bot, so forged updates aren't handled.
+func NewGuard(logger *slog.Logger, secret string, networks []netip.Prefix, next http.Handler) http.Handler {
+	logger = logger.With(slog.String("component", "webhook/guard"))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(networks) > 0 && !allowed(r.RemoteAddr, networks) {
+			logger.Warn("webhook request from unknown address rejected", slog.String("remoteAddr", r.RemoteAddr))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretHeader)), []byte(secret)) != 1 {
+			logger.Warn("webhook request with invalid secret token rejected", slog.String("remoteAddr", r.RemoteAddr))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowed reports whether the address belongs to one of the networks.
+func allowed(remoteAddr string, networks []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	return slices.ContainsFunc(networks, func(network netip.Prefix) bool {
+		return network.Contains(addr)
+	})
+}
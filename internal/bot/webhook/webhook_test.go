@@ -0,0 +1,96 @@
+package webhook_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"testing"
+
+	"protomorphine/tg-notes/internal/bot/webhook"
+	"protomorphine/tg-notes/internal/log"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard(t *testing.T) {
+	telegram := []netip.Prefix{netip.MustParsePrefix("149.154.160.0/20"), netip.MustParsePrefix("91.108.4.0/22")}
+
+	tests := []struct {
+		name           string
+		networks       []netip.Prefix
+		remoteAddr     string
+		secret         string
+		expectedStatus int
+	}{
+		{
+			name:           "valid secret",
+			remoteAddr:     "203.0.113.1:443",
+			secret:         "secret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing secret",
+			remoteAddr:     "203.0.113.1:443",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid secret",
+			remoteAddr:     "203.0.113.1:443",
+			secret:         "guessed",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "allowed network",
+			networks:       telegram,
+			remoteAddr:     "149.154.167.220:443",
+			secret:         "secret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "allowed network mapped to IPv6",
+			networks:       telegram,
+			remoteAddr:     "[::ffff:91.108.6.1]:443",
+			secret:         "secret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown network",
+			networks:       telegram,
+			remoteAddr:     "203.0.113.1:443",
+			secret:         "secret",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var handled bool
+			next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { handled = true })
+
+			guard := webhook.NewGuard(slog.New(log.NewDiscardHandler()), "secret", tc.networks, next)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.secret != "" {
+				req.Header.Set(webhook.SecretHeader, tc.secret)
+			}
+
+			rec := httptest.NewRecorder()
+			guard.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatus, rec.Code)
+			require.Equal(t, tc.expectedStatus == http.StatusOK, handled)
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret := webhook.NewSecret()
+
+	require.Regexp(t, regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`), secret)
+	require.NotEqual(t, secret, webhook.NewSecret())
+}
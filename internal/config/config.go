@@ -2,6 +2,10 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -9,20 +13,49 @@ import (
 
 // Config represents the application's configuration.
 type Config struct {
-	Environment   string           `yaml:"environment" env-default:"prod"` // current environment
-	Logger        LoggerConfig     `yaml:"logger"`                         // logger configuration
-	Bot           BotConfig        `yaml:"bot"`                            // Telegram bot configuration
-	HTTPServer    HTTPServerConfig `yaml:"httpServer"`                     // HTTP server configuration
-	GitRepository GitRepository    `yaml:"gitRepository"`                  // git repository configuration
-	NoteSave      NoteSaveConfig   `yaml:"noteSave"`                       // note save configuration
+	Environment     string            `yaml:"environment" env-default:"prod"`   // current environment
+	ShutdownTimeout time.Duration     `yaml:"shutdownTimeout" env-default:"8s"` // time to finish handlers and save pending notes on shutdown
+	Logger          LoggerConfig      `yaml:"logger"`                           // logger configuration
+	Bot             BotConfig         `yaml:"bot"`                              // Telegram bot configuration
+	HTTPServer      HTTPServerConfig  `yaml:"httpServer"`                       // HTTP server configuration
+	Storage         StorageConfig     `yaml:"storage"`                          // notes storage configuration
+	GitRepository   GitRepository     `yaml:"gitRepository"`                    // git repository configuration
+	WebDAV          WebDAVConfig      `yaml:"webdav"`                           // WebDAV server configuration
+	NoteSave        NoteSaveConfig    `yaml:"noteSave"`                         // note save configuration
+	Search          SearchConfig      `yaml:"search"`                           // notes search configuration
+	Classifier      ClassifierConfig  `yaml:"classifier"`                       // notes classifier configuration
+	Attachments     AttachmentsConfig `yaml:"attachments"`                      // message attachments configuration
+	API             APIConfig         `yaml:"api"`                              // HTTP API configuration
+	Users           []UserConfig      `yaml:"users"`                            // users with own repositories; if empty, single user is made of AllowedUserID, Storage, GitRepository, WebDAV, NoteSave, Classifier and API
 }
 
+// UserConfig represents settings of a single bot user.
+type UserConfig struct {
+	ID            int64            `yaml:"id"`            // user Telegram ID
+	Storage       StorageConfig    `yaml:"storage"`       // user's notes storage configuration
+	GitRepository GitRepository    `yaml:"gitRepository"` // user's notes repository configuration, used by git storage
+	WebDAV        WebDAVConfig     `yaml:"webdav"`        // user's WebDAV server configuration, used by webdav storage
+	NoteSave      NoteSaveConfig   `yaml:"noteSave"`      // user's note save configuration
+	Classifier    ClassifierConfig `yaml:"classifier"`    // user's notes classifier configuration
+	API           APIConfig        `yaml:"api"`           // user's HTTP API access
+}
+
+// Bot update receiving modes.
+const (
+	BotModeWebhook = "webhook" // Telegram sends updates to the WebHookURL
+	BotModePolling = "polling" // bot requests updates via long polling
+)
+
 // BotConfig represents the Telegram bot's configuration.
 type BotConfig struct {
-	Key           string        `env:"TG_API_KEY" env-required:"true"`                    // bot API key
-	InitTimeout   time.Duration `yaml:"initTimeout" env-default:"1m"`                     // bot init timeout
-	WebHookURL    string        `yaml:"webHookURL" env:"WEBHOOK_URL" env-required:"true"` // URL where Telegram will send updates
-	AllowedUserID int64         `yaml:"allowedUserID"`                                    // user ID, which allowed to perform actions
+	Key           string        `env:"TG_API_KEY"`                                 // bot API key, required to run the bot
+	Mode          string        `yaml:"mode" env:"BOT_MODE" env-default:"webhook"` // update receiving mode: webhook or polling
+	ServerURL     string        `yaml:"serverURL" env:"TG_SERVER_URL"`             // Telegram Bot API server URL, default one is used if empty
+	InitTimeout   time.Duration `yaml:"initTimeout" env-default:"1m"`              // bot init timeout
+	WebHookURL    string        `yaml:"webHookURL" env:"WEBHOOK_URL"`              // URL where Telegram will send updates, required in webhook mode
+	WebhookSecret string        `yaml:"webhookSecret" env:"WEBHOOK_SECRET"`        // token Telegram sends with webhook updates; random one is made on start, if empty
+	WebhookIPs    []string      `yaml:"webhookIPs"`                                // CIDR ranges webhook updates are accepted from, any if empty
+	AllowedUserID int64         `yaml:"allowedUserID"`                             // user ID, which allowed to perform actions; used if users list is empty
 }
 
 // LoggerConfig represents the logger's configuration.
@@ -35,39 +68,388 @@ type HTTPServerConfig struct {
 	Addr string `yaml:"addr" env-default:":80"` // address to bind
 }
 
+// APIConfig represents access to the HTTP API.
+type APIConfig struct {
+	Token string `yaml:"token" env:"API_TOKEN"` // bearer token of the user; API is disabled for the user, if empty
+}
+
 // NoteSaveConfig represents configuration for saving new notes.
 type NoteSaveConfig struct {
-	DefaultCategory   string  `yaml:"defaultCategory" env-default:"bot-notes"` // default note category
-	CategoryThreshold float64 `yaml:"categoryThreshold"`                       // threshold to use classifier category prediction
+	DefaultCategory   string  `yaml:"defaultCategory"`   // default note category, "bot-notes" if empty
+	CategoryThreshold float64 `yaml:"categoryThreshold"` // threshold to use classifier category prediction
+	CandidatesCount   int     `yaml:"candidatesCount"`   // count of predicted categories offered to choose after save, 3 if empty
 }
 
+// ClassifierConfig represents configuration of notes classifier.
+type ClassifierConfig struct {
+	ModelPath string `yaml:"modelPath" env:"CLASSIFIER_MODEL_PATH"` // file to persist trained model, model is retrained on every start if empty
+}
+
+// SearchConfig represents configuration for notes search.
+type SearchConfig struct {
+	ResultsLimit  int `yaml:"resultsLimit" env-default:"5"`    // max count of notes in search reply
+	SnippetLength int `yaml:"snippetLength" env-default:"200"` // max length of note snippet in search reply
+}
+
+// AttachmentsConfig represents configuration of files attached to notes.
+type AttachmentsConfig struct {
+	MaxSize      int64    `yaml:"maxSize" env-default:"20971520"`                               // max attachment size in bytes
+	AllowedTypes []string `yaml:"allowedTypes" env-default:"image/*,application/pdf,audio/ogg"` // allowed MIME types, wildcards are supported
+}
+
+// Storage types.
+const (
+	StorageGit    = "git"    // notes are committed and pushed to a remote git repository
+	StorageFS     = "fs"     // notes are saved to a local directory
+	StorageWebDAV = "webdav" // notes are uploaded to a WebDAV server, e.g. Nextcloud
+)
+
+// StorageConfig represents the notes storage configuration.
+type StorageConfig struct {
+	Type            string `yaml:"type"`            // storage type: git, fs or webdav; "git" if empty
+	Path            string `yaml:"path"`            // notes directory of fs storage, required for it
+	CollisionPolicy string `yaml:"collisionPolicy"` // what to do when note name is taken: suffix, append or reject; "suffix" if empty
+}
+
+// Note name collision policies.
+const (
+	CollisionSuffix = "suffix" // save note under name with numeric suffix
+	CollisionAppend = "append" // append note to the existing one
+	CollisionReject = "reject" // don't save note
+)
+
 // GitRepository represents the Git repository's configuration.
 type GitRepository struct {
-	URL             string        `yaml:"url" env-required:"true"`            // remote repo URL
-	Path            string        `yaml:"path"`                               // local path to clone repo
-	Auth            GitAuth       `yaml:"auth"`                               // git authentication config
-	Branch          string        `yaml:"branch"`                             // repo working branch
-	RemoteName      string        `yaml:"remoteName" env-default:"origin"`    // git remote name
-	Committer       Committer     `yaml:"committer"`                          // committer info
-	BufSize         int           `yaml:"bufSize" env-required:"true"`        // notes buffer size
-	UpdateDuratiion time.Duration `yaml:"updateDuration" env-required:"true"` // duration to fill buffer; save occurs when buffer is full or last save was specified time ago
+	URL             string        `yaml:"url"`            // remote repo URL, required
+	Path            string        `yaml:"path"`           // local path to clone repo
+	Auth            GitAuth       `yaml:"auth"`           // git authentication config
+	Branch          string        `yaml:"branch"`         // repo working branch
+	RemoteName      string        `yaml:"remoteName"`     // git remote name, "origin" if empty
+	Committer       Signature     `yaml:"committer"`      // committer info, email is required
+	Author          Signature     `yaml:"author"`         // author info with both name and email, committer is the author if empty
+	CommitMessage   string        `yaml:"commitMessage"`  // commit message template in text/template syntax, default one is used if empty
+	Signing         SigningConfig `yaml:"signing"`        // commit signing config
+	BufSize         int           `yaml:"bufSize"`        // notes buffer size, required
+	UpdateDuratiion time.Duration `yaml:"updateDuration"` // duration to fill buffer, required; save occurs when buffer is full or last save was specified time ago
+	Retry           RetryConfig   `yaml:"retry"`          // retry policy of failed saves
+
+	// Deprecated: use StorageConfig.CollisionPolicy. It's used, if storage collisionPolicy is empty.
+	CollisionPolicy string `yaml:"collisionPolicy"`
+}
+
+// RetryConfig represents the retry policy of failed saves to remote repository.
+type RetryConfig struct {
+	MaxAttempts  int           `yaml:"maxAttempts"`  // max save attempts per update, 5 if empty; changes are saved on the next update if all attempts fail
+	InitialDelay time.Duration `yaml:"initialDelay"` // delay before the first retry, "1s" if empty
+	MaxDelay     time.Duration `yaml:"maxDelay"`     // max delay between retries, "1m" if empty
+	Jitter       float64       `yaml:"jitter"`       // max random deviation of the delay as a fraction of it, from 0 to 1
 }
 
+// WebDAVConfig represents the WebDAV server configuration.
+type WebDAVConfig struct {
+	URL            string        `yaml:"url"`                    // URL of the notes directory on the server, required
+	User           string        `yaml:"user" env:"WEBDAV_USER"` // user name for basic auth, no auth is used if empty
+	Password       string        `env:"WEBDAV_PASSWORD"`         // password or app password for basic auth
+	Path           string        `yaml:"path"`                   // local directory to keep a copy of notes, required
+	Timeout        time.Duration `yaml:"timeout"`                // timeout of a request to the server, "30s" if empty
+	BufSize        int           `yaml:"bufSize"`                // changed files buffer size, required
+	UpdateDuration time.Duration `yaml:"updateDuration"`         // duration to fill buffer, required; upload occurs when buffer is full or last upload was specified time ago
+}
+
+// Git authentication types.
+const (
+	AuthSSH      = "ssh"       // SSH private key
+	AuthSSHAgent = "ssh-agent" // keys of the running SSH agent
+	AuthHTTP     = "http"      // HTTP basic auth with user and password or access token
+	AuthToken    = "token"     // HTTP bearer token
+	AuthNone     = "none"      // no authentication, e.g. for file:// remotes
+)
+
 // GitAuth represents the Git authentication configuration.
 type GitAuth struct {
-	Key         string `env:"KEY"`        // ssh key to access repo
-	KeyPassword string `env:"KEY_PASSWD"` // password to ssh key
+	Type                  string `yaml:"type"`                                  // authentication type: ssh, ssh-agent, http, token or none; chosen by repo URL scheme if empty
+	Key                   string `env:"KEY"`                                    // ssh key to access repo
+	KeyPassword           string `env:"KEY_PASSWD"`                             // password to ssh key
+	User                  string `yaml:"user" env:"GIT_USER"`                   // user name for ssh and http auth, "git" if empty
+	Token                 string `env:"GIT_TOKEN"`                              // password or access token for http and token auth
+	KnownHosts            string `yaml:"knownHosts" env:"KNOWN_HOSTS"`          // known_hosts content to verify ssh host key, e.g. output of ssh-keyscan
+	KnownHostsFile        string `yaml:"knownHostsFile" env:"KNOWN_HOSTS_FILE"` // known_hosts file path to verify ssh host key; ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts if both are empty
+	InsecureIgnoreHostKey bool   `yaml:"insecureIgnoreHostKey"`                 // don't verify ssh host key, e.g. for testing
+}
+
+// Commit signature formats.
+const (
+	SignOpenPGP = "openpgp" // OpenPGP signature, like gpg makes
+	SignSSH     = "ssh"     // SSH signature, like git makes with gpg.format=ssh
+)
+
+// SigningConfig represents the commit signing configuration.
+type SigningConfig struct {
+	Format     string `yaml:"format"`                // signature format: openpgp or ssh; detected by the key if empty
+	Key        string `env:"SIGNING_KEY"`            // armored OpenPGP private key or SSH private key; commits aren't signed if empty
+	Passphrase string `env:"SIGNING_KEY_PASSPHRASE"` // passphrase of the private key
 }
 
-// Committer represents the committer's information.
-type Committer struct {
-	Name string `yaml:"name"` // commiter name
+// Signature represents the committer's or author's information.
+type Signature struct {
+	Name  string `yaml:"name"`  // commiter or author name
+	Email string `yaml:"email"` // commiter or author email
 }
 
 // Load loads the configuration from the given path.
 func Load(configPath string) (*Config, error) {
 	var config Config
 
-	err := cleanenv.ReadConfig(configPath, &config)
-	return &config, err
+	if err := cleanenv.ReadConfig(configPath, &config); err != nil {
+		return nil, err
+	}
+
+	if len(config.Users) > 0 && config.API.Token != "" {
+		// the token would be silently ignored, leaving the API inaccessible
+		return nil, errors.New("api.token (API_TOKEN) is used only in single-user mode, set api.token of each user instead")
+	}
+
+	if len(config.Users) == 0 {
+		config.Users = []UserConfig{{
+			ID:            config.Bot.AllowedUserID,
+			Storage:       config.Storage,
+			GitRepository: config.GitRepository,
+			WebDAV:        config.WebDAV,
+			NoteSave:      config.NoteSave,
+			Classifier:    config.Classifier,
+			API:           config.API,
+		}}
+	}
+
+	paths := make(map[string]int64, len(config.Users))
+	tokens := make(map[string]int64, len(config.Users))
+
+	// cleanenv doesn't process structs inside slices, so defaults and
+	// required fields of users are handled manually
+	for i := range config.Users {
+		user := &config.Users[i]
+		user.setDefaults()
+
+		if err := user.validate(); err != nil {
+			return nil, fmt.Errorf("user %d: %w", user.ID, err)
+		}
+
+		if id, ok := paths[user.StoragePath()]; ok {
+			return nil, fmt.Errorf("users %d and %d share the same storage path", id, user.ID)
+		}
+		paths[user.StoragePath()] = user.ID
+
+		if user.API.Token == "" {
+			continue
+		}
+
+		if id, ok := tokens[user.API.Token]; ok {
+			return nil, fmt.Errorf("users %d and %d share the same API token", id, user.ID)
+		}
+		tokens[user.API.Token] = user.ID
+	}
+
+	return &config, nil
+}
+
+// User returns configuration of the user with given ID.
+func (c *Config) User(id int64) (*UserConfig, bool) {
+	for i := range c.Users {
+		if c.Users[i].ID == id {
+			return &c.Users[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// APITokens returns IDs of users, which have access to the HTTP API, by their tokens.
+func (c *Config) APITokens() map[string]int64 {
+	tokens := make(map[string]int64)
+
+	for _, user := range c.Users {
+		if user.API.Token != "" {
+			tokens[user.API.Token] = user.ID
+		}
+	}
+
+	return tokens
+}
+
+func (u *UserConfig) setDefaults() {
+	if u.GitRepository.RemoteName == "" {
+		u.GitRepository.RemoteName = "origin"
+	}
+
+	if u.Storage.Type == "" {
+		u.Storage.Type = StorageGit
+	}
+
+	// configs made before the storage section keep the policy in the repository section
+	if u.Storage.CollisionPolicy == "" {
+		u.Storage.CollisionPolicy = u.GitRepository.CollisionPolicy
+	}
+
+	if u.Storage.CollisionPolicy == "" {
+		u.Storage.CollisionPolicy = CollisionSuffix
+	}
+
+	if u.GitRepository.Retry.MaxAttempts == 0 {
+		u.GitRepository.Retry.MaxAttempts = 5
+	}
+
+	if u.GitRepository.Retry.InitialDelay == 0 {
+		u.GitRepository.Retry.InitialDelay = time.Second
+	}
+
+	if u.GitRepository.Retry.MaxDelay == 0 {
+		u.GitRepository.Retry.MaxDelay = time.Minute
+	}
+
+	if u.WebDAV.Timeout == 0 {
+		u.WebDAV.Timeout = 30 * time.Second
+	}
+
+	if u.NoteSave.DefaultCategory == "" {
+		u.NoteSave.DefaultCategory = "bot-notes"
+	}
+
+	if u.NoteSave.CandidatesCount == 0 {
+		u.NoteSave.CandidatesCount = 3
+	}
+}
+
+// StoragePath returns local path of the user's notes.
+func (u *UserConfig) StoragePath() string {
+	switch u.Storage.Type {
+	case StorageFS:
+		return u.Storage.Path
+	case StorageWebDAV:
+		return u.WebDAV.Path
+	default:
+		return u.GitRepository.Path
+	}
+}
+
+func (u *UserConfig) validate() error {
+	if u.ID == 0 {
+		return errors.New("user ID is required")
+	}
+
+	switch u.Storage.CollisionPolicy {
+	case CollisionSuffix, CollisionAppend, CollisionReject:
+	default:
+		return fmt.Errorf("unknown storage collisionPolicy: %q", u.Storage.CollisionPolicy)
+	}
+
+	switch u.Storage.Type {
+	case StorageGit:
+		return u.GitRepository.validate()
+	case StorageFS:
+		if u.Storage.Path == "" {
+			return errors.New("storage path is required for fs storage")
+		}
+		return nil
+	case StorageWebDAV:
+		return u.WebDAV.validate()
+	default:
+		return fmt.Errorf("unknown storage type: %q", u.Storage.Type)
+	}
+}
+
+func (r *GitRepository) validate() error {
+	switch {
+	case r.URL == "":
+		return errors.New("git repository URL is required")
+	case r.BufSize <= 0:
+		return errors.New("git repository bufSize should be positive")
+	case r.UpdateDuratiion <= 0:
+		return errors.New("git repository updateDuration should be positive")
+	case r.Committer.Email == "":
+		return errors.New("git repository committer email is required")
+	case (r.Author.Name == "") != (r.Author.Email == ""):
+		return errors.New("git repository author needs both name and email")
+	case r.Retry.MaxAttempts < 0:
+		return errors.New("git repository retry maxAttempts should be positive")
+	case r.Retry.InitialDelay < 0 || r.Retry.MaxDelay < r.Retry.InitialDelay:
+		return errors.New("git repository retry delays should be positive, maxDelay should be not less than initialDelay")
+	case r.Retry.Jitter < 0 || r.Retry.Jitter > 1:
+		return errors.New("git repository retry jitter should be from 0 to 1")
+	}
+
+	switch r.Auth.Type {
+	case "", AuthSSH, AuthSSHAgent, AuthHTTP, AuthToken, AuthNone:
+	default:
+		return fmt.Errorf("unknown git repository auth type: %q", r.Auth.Type)
+	}
+
+	switch r.Signing.Format {
+	case "", SignOpenPGP, SignSSH:
+	default:
+		return fmt.Errorf("unknown git repository signing format: %q", r.Signing.Format)
+	}
+
+	return nil
+}
+
+func (w *WebDAVConfig) validate() error {
+	switch {
+	case w.URL == "":
+		return errors.New("webdav URL is required")
+	case w.Path == "":
+		return errors.New("webdav path is required")
+	case w.BufSize <= 0:
+		return errors.New("webdav bufSize should be positive")
+	case w.UpdateDuration <= 0:
+		return errors.New("webdav updateDuration should be positive")
+	}
+
+	return nil
+}
+
+// webhookSecretRe matches secret tokens accepted by Telegram.
+var webhookSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// WebhookNetworks returns parsed CIDR ranges, which webhook updates are accepted from.
+func (c *BotConfig) WebhookNetworks() ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(c.WebhookIPs))
+
+	for _, ip := range c.WebhookIPs {
+		network, err := netip.ParsePrefix(ip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhookIPs range: %w", err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// Validate checks dependencies between bot configuration fields,
+// which can't be expressed via struct tags.
+func (c *BotConfig) Validate() error {
+	if c.Key == "" {
+		return errors.New("TG_API_KEY is required")
+	}
+
+	switch c.Mode {
+	case BotModeWebhook:
+		if c.WebHookURL == "" {
+			return errors.New("WEBHOOK_URL is required in webhook mode")
+		}
+
+		if c.WebhookSecret != "" && !webhookSecretRe.MatchString(c.WebhookSecret) {
+			return errors.New("WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+
+		if _, err := c.WebhookNetworks(); err != nil {
+			return err
+		}
+	case BotModePolling:
+	default:
+		return fmt.Errorf("unknown bot mode: %q", c.Mode)
+	}
+
+	return nil
 }
@@ -0,0 +1,287 @@
+package config_test
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"protomorphine/tg-notes/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// baseConfig is a minimal valid config, which tests extend.
+const baseConfig = `
+bot:
+  allowedUserID: 1
+gitRepository:
+  url: "git@example.com:user/notes.git"
+  path: "/tmp/notes"
+  branch: "main"
+  bufSize: 1
+  updateDuration: 1m
+  committer:
+    name: "tg-notes"
+    email: "bot@example.com"
+`
+
+// usersConfig lists a user with own repository and API token.
+const usersConfig = `
+users:
+  - id: 2
+    gitRepository:
+      url: "git@example.com:user/notes.git"
+      path: "/tmp/notes-2"
+      branch: "main"
+      bufSize: 1
+      updateDuration: 1m
+      committer:
+        name: "tg-notes"
+        email: "bot@example.com"
+    api:
+      token: "user-token"
+`
+
+// load loads the config from given YAML content.
+func load(t *testing.T, content string) (*config.Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return config.Load(path)
+}
+
+func TestLoadCollisionPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "default",
+			content:  baseConfig,
+			expected: config.CollisionSuffix,
+		},
+		{
+			name:     "storage policy",
+			content:  baseConfig + "storage:\n  collisionPolicy: append\n",
+			expected: config.CollisionAppend,
+		},
+		{
+			name:     "deprecated repository policy",
+			content:  baseConfig + "  collisionPolicy: reject\n",
+			expected: config.CollisionReject,
+		},
+		{
+			name:     "storage policy overrides repository one",
+			content:  baseConfig + "  collisionPolicy: reject\nstorage:\n  collisionPolicy: append\n",
+			expected: config.CollisionAppend,
+		},
+		{
+			name:        "unknown repository policy",
+			content:     baseConfig + "  collisionPolicy: overwrite\n",
+			expectedErr: `unknown storage collisionPolicy: "overwrite"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := load(t, tc.content)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cfg.Users[0].Storage.CollisionPolicy)
+		})
+	}
+}
+
+func TestLoadCommitSignatures(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:    "committer only",
+			content: baseConfig,
+		},
+		{
+			name:    "author with name and email",
+			content: baseConfig + "  author:\n    name: \"Alice\"\n    email: \"alice@example.com\"\n",
+		},
+		{
+			name:        "author without name",
+			content:     baseConfig + "  author:\n    email: \"alice@example.com\"\n",
+			expectedErr: "author needs both name and email",
+		},
+		{
+			name:        "author without email",
+			content:     baseConfig + "  author:\n    name: \"Alice\"\n",
+			expectedErr: "author needs both name and email",
+		},
+		{
+			name:        "committer without email",
+			content:     strings.Replace(baseConfig, "    email: \"bot@example.com\"\n", "", 1),
+			expectedErr: "committer email is required",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(t, tc.content)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadAPIToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    map[string]int64
+		expectedErr string
+	}{
+		{
+			name:     "single user",
+			content:  baseConfig + "api:\n  token: \"single-token\"\n",
+			expected: map[string]int64{"single-token": 1},
+		},
+		{
+			name:     "token of user",
+			content:  baseConfig + usersConfig,
+			expected: map[string]int64{"user-token": 2},
+		},
+		{
+			name:        "top-level token with users",
+			content:     baseConfig + usersConfig + "api:\n  token: \"single-token\"\n",
+			expectedErr: "api.token (API_TOKEN) is used only in single-user mode",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := load(t, tc.content)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cfg.APITokens())
+		})
+	}
+}
+
+func TestLoadAPITokenFromEnvWithUsers(t *testing.T) {
+	t.Setenv("API_TOKEN", "env-token")
+
+	_, err := load(t, baseConfig+usersConfig)
+	require.ErrorContains(t, err, "api.token (API_TOKEN) is used only in single-user mode")
+}
+
+func TestBotValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		bot         config.BotConfig
+		expectedErr string
+	}{
+		{
+			name: "webhook",
+			bot: config.BotConfig{
+				Key:           "key",
+				Mode:          config.BotModeWebhook,
+				WebHookURL:    "https://example.com/",
+				WebhookSecret: "Secret_token-1",
+				WebhookIPs:    []string{"149.154.160.0/20", "2001:67c:4e8::/48"},
+			},
+		},
+		{
+			name: "webhook with generated secret",
+			bot:  config.BotConfig{Key: "key", Mode: config.BotModeWebhook, WebHookURL: "https://example.com/"},
+		},
+		{
+			name: "polling ignores webhook fields",
+			bot:  config.BotConfig{Key: "key", Mode: config.BotModePolling, WebhookSecret: "bad secret", WebhookIPs: []string{"bad"}},
+		},
+		{
+			name: "secret with invalid characters",
+			bot: config.BotConfig{
+				Key:           "key",
+				Mode:          config.BotModeWebhook,
+				WebHookURL:    "https://example.com/",
+				WebhookSecret: "bad secret!",
+			},
+			expectedErr: "WEBHOOK_SECRET must be 1-256 characters",
+		},
+		{
+			name: "too long secret",
+			bot: config.BotConfig{
+				Key:           "key",
+				Mode:          config.BotModeWebhook,
+				WebHookURL:    "https://example.com/",
+				WebhookSecret: strings.Repeat("a", 257),
+			},
+			expectedErr: "WEBHOOK_SECRET must be 1-256 characters",
+		},
+		{
+			name: "invalid CIDR",
+			bot: config.BotConfig{
+				Key:        "key",
+				Mode:       config.BotModeWebhook,
+				WebHookURL: "https://example.com/",
+				WebhookIPs: []string{"149.154.160.0/20", "149.154.160.0"},
+			},
+			expectedErr: "invalid webhookIPs range",
+		},
+		{
+			name:        "webhook without URL",
+			bot:         config.BotConfig{Key: "key", Mode: config.BotModeWebhook},
+			expectedErr: "WEBHOOK_URL is required in webhook mode",
+		},
+		{
+			name:        "unknown mode",
+			bot:         config.BotConfig{Key: "key", Mode: "push"},
+			expectedErr: `unknown bot mode: "push"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.bot.Validate()
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWebhookNetworks(t *testing.T) {
+	bot := config.BotConfig{WebhookIPs: []string{"149.154.160.0/20", "2001:67c:4e8::/48"}}
+
+	networks, err := bot.WebhookNetworks()
+	require.NoError(t, err)
+	require.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("149.154.160.0/20"),
+		netip.MustParsePrefix("2001:67c:4e8::/48"),
+	}, networks)
+
+	bot.WebhookIPs = []string{"not a network"}
+
+	_, err = bot.WebhookNetworks()
+	require.ErrorContains(t, err, "invalid webhookIPs range")
+}
@@ -1,12 +1,98 @@
 // Package domain contains domain types.
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// AssetsDir is a directory inside category, where note attachments are stored.
+const AssetsDir = "assets"
+
+// ErrNoteExists is returned when a note with the same name already exists in the category.
+var ErrNoteExists = errors.New("note already exists")
+
+// ErrNoteNotFound is returned when there is no note with given name in the category.
+var ErrNoteNotFound = errors.New("note not found")
+
+// SaveOutcome describes how a new note was written to the storage.
+type SaveOutcome string
+
+const (
+	SaveCreated  SaveOutcome = "created"  // note was saved under requested name
+	SaveRenamed  SaveOutcome = "renamed"  // name was taken, note was saved under name with suffix
+	SaveAppended SaveOutcome = "appended" // name was taken, note was appended to existing one
+)
+
 type Category string
 
 // Note struct represent a note.
 type Note struct {
-	Title    string
-	Content  string
-	Category Category
+	Title       string
+	Name        string // file name without extension, unique within category
+	Content     string
+	Category    Category
+	Attachments []Attachment
+	Meta        Metadata
+}
+
+// Metadata represents note properties stored along with its content.
+type Metadata struct {
+	Created       time.Time `yaml:"created,omitempty"`       // note creation time
+	ChatID        int64     `yaml:"chatID,omitempty"`        // ID of the chat, where note was sent
+	MessageID     int       `yaml:"messageID,omitempty"`     // ID of the message, which note was made of
+	ForwardedFrom string    `yaml:"forwardedFrom,omitempty"` // original author of the forwarded message
+	Tags          []string  `yaml:"tags,omitempty"`          // message hashtags without leading '#'
+	Score         float64   `yaml:"score,omitempty"`         // classifier probability of the predicted category
+}
+
+// IsZero reports whether metadata is empty.
+func (m Metadata) IsZero() bool {
+	return m.Created.IsZero() && m.ChatID == 0 && m.MessageID == 0 &&
+		m.ForwardedFrom == "" && len(m.Tags) == 0 && m.Score == 0
+}
+
+// Attachment represents a file attached to a note.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// NotePath returns path of the note file relative to the storage root.
+func NotePath(category Category, name string) string {
+	return path.Join(string(category), name+".md")
 }
 
+// AssetPath returns path of the note attachment relative to the note file.
+func AssetPath(noteName, name string) string {
+	return path.Join(AssetsDir, noteName, name)
+}
+
+// Markdown returns note content followed by links to the note attachments.
+// Images are embedded.
+func (n Note) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString(n.Content)
+
+	for _, attachment := range n.Attachments {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+
+		// angle brackets allow spaces in link destination
+		link := fmt.Sprintf("[%s](<%s>)", attachment.Name, AssetPath(n.Name, attachment.Name))
+
+		switch strings.ToLower(path.Ext(attachment.Name)) {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+			b.WriteString("!" + link)
+		default:
+			b.WriteString(link)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package domain_test
+
+import (
+	"testing"
+
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown(t *testing.T) {
+	note := domain.Note{
+		Name:    "my-note",
+		Content: "text",
+		Attachments: []domain.Attachment{
+			{Name: "photo_1.jpg"},
+			{Name: "my doc.pdf"},
+		},
+	}
+
+	require.Equal(t, "text\n\n![photo_1.jpg](<assets/my-note/photo_1.jpg>)\n\n[my doc.pdf](<assets/my-note/my doc.pdf>)", note.Markdown())
+}
@@ -0,0 +1,108 @@
+// Package metrics provides Prometheus metrics of the application.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"protomorphine/tg-notes/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "tgnotes"
+
+// Metrics collects application metrics and registers them in the registry.
+type Metrics struct {
+	registerer prometheus.Registerer
+
+	notesSaved        *prometheus.CounterVec
+	categoryFallbacks prometheus.Counter
+	remoteDuration    *prometheus.HistogramVec
+	remoteFailures    *prometheus.CounterVec
+	handlerDuration   prometheus.Histogram
+}
+
+// New creates metrics and registers them with Go runtime and process metrics in the registerer.
+func New(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		registerer: registerer,
+		notesSaved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notes_saved_total",
+			Help:      "Count of saved notes by save outcome.",
+		}, []string{"outcome"}),
+		categoryFallbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "category_fallbacks_total",
+			Help:      "Count of notes saved to the default category, since the predicted one wasn't confident enough.",
+		}),
+		remoteDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "git_operation_duration_seconds",
+			Help:      "Duration of git operations with the remote repository.",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"operation"}),
+		remoteFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "git_operation_failures_total",
+			Help:      "Count of failed git operations with the remote repository.",
+		}, []string{"operation"}),
+		handlerDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "handler_duration_seconds",
+			Help:      "Duration of Telegram update handling.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	registerer.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.notesSaved,
+		m.categoryFallbacks,
+		m.remoteDuration,
+		m.remoteFailures,
+		m.handlerDuration,
+	)
+
+	return m
+}
+
+// NoteSaved counts the saved note.
+func (m *Metrics) NoteSaved(outcome domain.SaveOutcome, fallback bool) {
+	m.notesSaved.WithLabelValues(string(outcome)).Inc()
+
+	if fallback {
+		m.categoryFallbacks.Inc()
+	}
+}
+
+// ObserveRemote records duration of the git operation with the remote repository
+// and counts it, if it failed.
+func (m *Metrics) ObserveRemote(operation string, duration time.Duration, err error) {
+	m.remoteDuration.WithLabelValues(operation).Observe(duration.Seconds())
+
+	if err != nil {
+		m.remoteFailures.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveHandler records duration of the update handling.
+func (m *Metrics) ObserveHandler(duration time.Duration) {
+	m.handlerDuration.Observe(duration.Seconds())
+}
+
+// RegisterPending registers gauge of changes, which the user's storage hasn't saved
+// to the remote yet. Value is taken from pending on every scrape.
+func (m *Metrics) RegisterPending(userID int64, pending func() int) error {
+	return m.registerer.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "storage_pending_changes",
+		Help:        "Count of changed files, which aren't saved to the remote storage yet.",
+		ConstLabels: prometheus.Labels{"user": strconv.FormatInt(userID, 10)},
+	}, func() float64 {
+		return float64(pending())
+	}))
+}
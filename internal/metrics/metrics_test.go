@@ -0,0 +1,57 @@
+package metrics_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+
+	m.NoteSaved(domain.SaveCreated, false)
+	m.NoteSaved(domain.SaveCreated, true)
+	m.NoteSaved(domain.SaveAppended, false)
+
+	m.ObserveRemote("push", time.Second, nil)
+	m.ObserveRemote("push", time.Second, errors.New("push error"))
+
+	pending := 3
+	require.NoError(t, m.RegisterPending(42, func() int { return pending }))
+
+	expected := `
+# HELP tgnotes_category_fallbacks_total Count of notes saved to the default category, since the predicted one wasn't confident enough.
+# TYPE tgnotes_category_fallbacks_total counter
+tgnotes_category_fallbacks_total 1
+# HELP tgnotes_git_operation_failures_total Count of failed git operations with the remote repository.
+# TYPE tgnotes_git_operation_failures_total counter
+tgnotes_git_operation_failures_total{operation="push"} 1
+# HELP tgnotes_notes_saved_total Count of saved notes by save outcome.
+# TYPE tgnotes_notes_saved_total counter
+tgnotes_notes_saved_total{outcome="appended"} 1
+tgnotes_notes_saved_total{outcome="created"} 2
+# HELP tgnotes_storage_pending_changes Count of changed files, which aren't saved to the remote storage yet.
+# TYPE tgnotes_storage_pending_changes gauge
+tgnotes_storage_pending_changes{user="42"} 3
+`
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"tgnotes_category_fallbacks_total",
+		"tgnotes_git_operation_failures_total",
+		"tgnotes_notes_saved_total",
+		"tgnotes_storage_pending_changes",
+	)
+	require.NoError(t, err)
+
+	// one histogram per operation
+	require.Equal(t, 1, testutil.CollectAndCount(registry, "tgnotes_git_operation_duration_seconds"))
+}
@@ -0,0 +1,80 @@
+// Package frontmatter provides encoding of note metadata as YAML front matter.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"protomorphine/tg-notes/internal/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// delimiter separates front matter from the note body.
+const delimiter = "---"
+
+// fields represents front matter fields.
+type fields struct {
+	Title           string `yaml:"title,omitempty"`
+	domain.Metadata `yaml:",inline"`
+}
+
+// Encode returns note body preceded by front matter with title and metadata.
+// Body is returned as is if both title and metadata are empty.
+func Encode(title string, meta domain.Metadata, body string) ([]byte, error) {
+	const op = "storage.frontmatter.Encode"
+
+	if title == "" && meta.IsZero() {
+		return []byte(body), nil
+	}
+
+	header, err := yaml.Marshal(fields{Title: title, Metadata: meta})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(delimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(delimiter + "\n")
+	buf.WriteString(body)
+
+	return buf.Bytes(), nil
+}
+
+// Decode splits file content into title, metadata and note body.
+// Content without front matter is returned as body. Front matter, which isn't valid YAML,
+// is skipped, as well as fields, which don't match metadata format (e.g. edited by other tools).
+func Decode(content string) (string, domain.Metadata, string) {
+	rest, ok := strings.CutPrefix(content, delimiter+"\n")
+	if !ok {
+		return "", domain.Metadata{}, content
+	}
+
+	var header, body string
+
+	if after, ok := strings.CutPrefix(rest, delimiter+"\n"); ok {
+		body = after
+	} else if i := strings.Index(rest, "\n"+delimiter+"\n"); i >= 0 {
+		header, body = rest[:i+1], rest[i+len(delimiter)+2:]
+	} else if strings.HasSuffix(rest, "\n"+delimiter) {
+		header = strings.TrimSuffix(rest, delimiter)
+	} else {
+		return "", domain.Metadata{}, content
+	}
+
+	// fields of wrong type, e.g. scalar tags, are skipped by the decoder,
+	// and the rest of them are kept
+	var h fields
+	if err := yaml.Unmarshal([]byte(header), &h); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return "", domain.Metadata{}, body
+		}
+	}
+
+	return h.Title, h.Metadata, body
+}
@@ -0,0 +1,94 @@
+package frontmatter_test
+
+import (
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/storage/frontmatter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	meta := domain.Metadata{
+		Created:       time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC),
+		ChatID:        42,
+		MessageID:     7,
+		ForwardedFrom: "Channel",
+		Tags:          []string{"go", "notes"},
+		Score:         .75,
+	}
+
+	content, err := frontmatter.Encode("Note: title", meta, "note body\n---\nwith delimiter")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "---\ntitle: 'Note: title'\ncreated: 2025-03-01T10:30:00Z\n")
+
+	title, decoded, body := frontmatter.Decode(string(content))
+	require.Equal(t, "Note: title", title)
+	require.Equal(t, meta, decoded)
+	require.Equal(t, "note body\n---\nwith delimiter", body)
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	content, err := frontmatter.Encode("", domain.Metadata{}, "note body")
+	require.NoError(t, err)
+	require.Equal(t, "note body", string(content))
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		content      string
+		expectedMeta domain.Metadata
+		expectedBody string
+	}{
+		{
+			name:         "no front matter",
+			content:      "note body",
+			expectedBody: "note body",
+		},
+		{
+			name:         "unclosed front matter",
+			content:      "---\ntags: [go]\nnote body",
+			expectedBody: "---\ntags: [go]\nnote body",
+		},
+		{
+			name:         "empty front matter",
+			content:      "---\n---\nnote body",
+			expectedBody: "note body",
+		},
+		{
+			name:         "front matter without body",
+			content:      "---\nchatID: 1\n---",
+			expectedMeta: domain.Metadata{ChatID: 1},
+		},
+		{
+			name:         "unknown fields are ignored",
+			content:      "---\naliases: [x]\ntags: [go]\n---\nnote body",
+			expectedMeta: domain.Metadata{Tags: []string{"go"}},
+			expectedBody: "note body",
+		},
+		{
+			name:         "field of wrong type is skipped",
+			content:      "---\nchatID: 1\ntags: go\nmessageID: 2\n---\nnote body",
+			expectedMeta: domain.Metadata{ChatID: 1, MessageID: 2},
+			expectedBody: "note body",
+		},
+		{
+			name:         "malformed front matter is skipped",
+			content:      "---\nchatID: 1\ntags: [go\n---\nnote body",
+			expectedBody: "note body",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, meta, body := frontmatter.Decode(tc.content)
+			require.Equal(t, tc.expectedMeta, meta)
+			require.Equal(t, tc.expectedBody, body)
+		})
+	}
+}
@@ -0,0 +1,545 @@
+// Package fs provides storage of notes as Markdown files in a local directory.
+// Notes are grouped by category directories, attachments are stored next to the note.
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/storage/frontmatter"
+
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/osfs"
+)
+
+// ChangeHandler is called with paths of changed files, relative to the storage root.
+// It is called under the storage lock, so changes are reported in order.
+type ChangeHandler func(paths ...string) error
+
+// Storage represents a storage of notes in a directory.
+type Storage struct {
+	fs              billy.Filesystem
+	collisionPolicy string
+	onChange        ChangeHandler
+
+	mu sync.Mutex
+}
+
+// New creates a new Storage in the configured directory. The directory is created
+// if it doesn't exist.
+func New(cfg *config.StorageConfig) (*Storage, error) {
+	const op = "storage.fs.New"
+
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return NewFS(osfs.New(cfg.Path), cfg.CollisionPolicy, nil), nil
+}
+
+// NewFS creates a new Storage in the given filesystem. Changed files are reported
+// to onChange, if it isn't nil, e.g. to save them to a remote repository.
+func NewFS(fs billy.Filesystem, collisionPolicy string, onChange ChangeHandler) *Storage {
+	return &Storage{
+		fs:              fs,
+		collisionPolicy: collisionPolicy,
+		onChange:        onChange,
+	}
+}
+
+// Add adds a new note to the storage and returns the stored note. If the note name
+// is already taken in the category, configured collision policy is applied.
+func (s *Storage) Add(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error) {
+	const op = "storage.fs.Add"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcome := domain.SaveCreated
+
+	if s.taken(note.Category, note.Name) {
+		policy := s.collisionPolicy
+
+		// only attachments may be left of the note, there is nothing to append to
+		if policy == config.CollisionAppend && !s.exists(domain.NotePath(note.Category, note.Name)) {
+			policy = config.CollisionSuffix
+		}
+
+		switch policy {
+		case config.CollisionReject:
+			return domain.Note{}, "", fmt.Errorf("%s: %w: %s", op, domain.ErrNoteExists, domain.NotePath(note.Category, note.Name))
+		case config.CollisionAppend:
+			outcome = domain.SaveAppended
+		default:
+			note.Name = s.uniqueName(note.Category, note.Name)
+			outcome = domain.SaveRenamed
+		}
+	}
+
+	var (
+		path string
+		err  error
+	)
+
+	if outcome == domain.SaveAppended {
+		path, note, err = s.appendNote(note)
+	} else {
+		path, note, err = s.createNote(note)
+	}
+
+	if err != nil {
+		return domain.Note{}, "", fmt.Errorf("%s: file save error: %w", op, err)
+	}
+
+	paths := []string{path}
+
+	for _, attachment := range note.Attachments {
+		path, err := s.createFile(string(note.Category), domain.AssetPath(note.Name, attachment.Name), attachment.Data)
+		if err != nil {
+			return domain.Note{}, "", fmt.Errorf("%s: attachment save error: %w", op, err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	if err := s.changed(paths...); err != nil {
+		return domain.Note{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, outcome, nil
+}
+
+// createNote writes a new note file and returns its path and the stored note.
+func (s *Storage) createNote(note domain.Note) (string, domain.Note, error) {
+	note.Content = note.Markdown()
+
+	content, err := frontmatter.Encode(note.Title, note.Meta, note.Content)
+	if err != nil {
+		return "", domain.Note{}, err
+	}
+
+	path, err := s.createFile(string(note.Category), note.Name+".md", content)
+	if err != nil {
+		return "", domain.Note{}, err
+	}
+
+	return path, note, nil
+}
+
+// appendNote appends note content to the existing note with the same name and returns
+// its path and the whole stored note. Front matter of the existing note is kept as is.
+func (s *Storage) appendNote(note domain.Note) (string, domain.Note, error) {
+	path := domain.NotePath(note.Category, note.Name)
+
+	existing, err := s.readFile(path)
+	if err != nil {
+		return "", domain.Note{}, err
+	}
+
+	// attachments of both notes share the same directory
+	note.Attachments = s.uniqueAttachments(note)
+
+	var b strings.Builder
+
+	b.WriteString(existing)
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(note.Markdown())
+
+	if _, err := s.createFile(string(note.Category), note.Name+".md", []byte(b.String())); err != nil {
+		return "", domain.Note{}, err
+	}
+
+	stored := parseNote(note.Name, note.Category, b.String())
+	stored.Attachments = note.Attachments
+
+	return path, stored, nil
+}
+
+// taken reports whether the name is used by a note or by attachments in the category.
+func (s *Storage) taken(category domain.Category, name string) bool {
+	return s.exists(domain.NotePath(category, name)) || s.exists(path.Join(string(category), domain.AssetPath(name, "")))
+}
+
+// exists reports whether the file exists in the storage.
+func (s *Storage) exists(path string) bool {
+	_, err := s.fs.Lstat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// uniqueName returns the name with the smallest numeric suffix, which isn't taken in the category.
+func (s *Storage) uniqueName(category domain.Category, name string) string {
+	candidate := name
+
+	for i := 2; s.taken(category, candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	return candidate
+}
+
+// uniqueAttachments returns note attachments renamed with numeric suffix,
+// so they don't overwrite existing attachments of the note or each other.
+func (s *Storage) uniqueAttachments(note domain.Note) []domain.Attachment {
+	attachments := slices.Clone(note.Attachments)
+	names := make(map[string]struct{}, len(attachments))
+
+	for i, attachment := range attachments {
+		ext := path.Ext(attachment.Name)
+		base := strings.TrimSuffix(attachment.Name, ext)
+
+		for n := 2; ; n++ {
+			_, seen := names[attachment.Name]
+			if !seen && !s.exists(path.Join(string(note.Category), domain.AssetPath(note.Name, attachment.Name))) {
+				break
+			}
+
+			attachment.Name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+
+		names[attachment.Name] = struct{}{}
+		attachments[i] = attachment
+	}
+
+	return attachments
+}
+
+// Move moves the note to another category and returns the moved note. Neither the note,
+// nor its attachments are moved, if the name is taken in the target category. Files moved
+// before a failure are moved back, so the note is kept together with its attachments.
+func (s *Storage) Move(ctx context.Context, name string, from, to domain.Category) (domain.Note, error) {
+	const op = "storage.fs.Move"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movedPath := domain.NotePath(to, name)
+
+	if s.taken(to, name) {
+		return domain.Note{}, fmt.Errorf("%s: %w: %s", op, domain.ErrNoteExists, movedPath)
+	}
+
+	// note file and its attachments, paths are relative to category
+	files := []string{name + ".md"}
+
+	assets, err := s.fs.ReadDir(path.Join(string(from), domain.AssetPath(name, "")))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return domain.Note{}, fmt.Errorf("%s: read attachments error: %w", op, err)
+	}
+
+	for _, asset := range assets {
+		files = append(files, domain.AssetPath(name, asset.Name()))
+	}
+
+	// the file is removed from the old path, so both paths are changed
+	paths := make([]string, 0, 2*len(files))
+
+	for _, file := range files {
+		oldPath, newPath := path.Join(string(from), file), path.Join(string(to), file)
+
+		if err := s.moveFile(oldPath, newPath); err != nil {
+			if undoErr := s.undoMove(paths); undoErr != nil {
+				err = errors.Join(err, fmt.Errorf("undo error: %w", undoErr))
+			}
+
+			_ = s.fs.Remove(path.Join(string(to), domain.AssetPath(name, "")))
+
+			return domain.Note{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		paths = append(paths, oldPath, newPath)
+	}
+
+	// changes are reported once all files are moved, so nothing is reported, if the move is undone
+	if err := s.changed(paths...); err != nil {
+		return domain.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_ = s.fs.Remove(path.Join(string(from), domain.AssetPath(name, "")))
+
+	content, err := s.readFile(movedPath)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("%s: read moved note error: %w", op, err)
+	}
+
+	return parseNote(name, to, content), nil
+}
+
+// moveFile renames the file, creating the directory of the new path.
+func (s *Storage) moveFile(oldPath, newPath string) error {
+	if err := s.fs.MkdirAll(path.Dir(newPath), 0o755); err != nil {
+		return fmt.Errorf("create directory error: %w", err)
+	}
+
+	if err := s.fs.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("rename file error: %w", err)
+	}
+
+	return nil
+}
+
+// undoMove moves files back in reverse order. paths are pairs of old and new paths of moved files.
+func (s *Storage) undoMove(paths []string) error {
+	var errs []error
+
+	for i := len(paths) - 2; i >= 0; i -= 2 {
+		if err := s.moveFile(paths[i+1], paths[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Categories returns all categories presented in the storage.
+func (s *Storage) Categories(ctx context.Context) ([]domain.Category, error) {
+	const op = "storage.fs.Categories"
+
+	entries, err := s.fs.ReadDir("/")
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read root directory: %w", op, err)
+	}
+
+	var categories []domain.Category
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		categories = append(categories, domain.Category(entry.Name()))
+	}
+
+	return categories, nil
+}
+
+// Notes returns all notes from the storage.
+func (s *Storage) Notes(ctx context.Context) ([]domain.Note, error) {
+	const op = "storage.fs.Notes"
+
+	var notes []domain.Note
+
+	err := s.walkNotes(func(category domain.Category, notePath string, _ os.DirEntry) error {
+		content, err := s.readFile(notePath)
+		if err != nil {
+			return fmt.Errorf("failed to read note %s: %w", notePath, err)
+		}
+
+		notes = append(notes, parseNote(strings.TrimSuffix(path.Base(notePath), ".md"), category, content))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notes, nil
+}
+
+// walkNotes calls fn for every note file in category directories.
+func (s *Storage) walkNotes(fn func(category domain.Category, path string, info os.DirEntry) error) error {
+	rootEntries, err := s.fs.ReadDir("/")
+	if err != nil {
+		return fmt.Errorf("failed to read root directory: %w", err)
+	}
+
+	for _, entry := range rootEntries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		category := domain.Category(entry.Name())
+		if err := s.walkDir(entry.Name(), category, fn); err != nil {
+			return fmt.Errorf("failed to read notes for category %s: %w", category, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) walkDir(currentPath string, category domain.Category, fn func(domain.Category, string, os.DirEntry) error) error {
+	entries, err := s.fs.ReadDir(currentPath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", currentPath, err)
+	}
+
+	for _, entry := range entries {
+		newPath := path.Join(currentPath, entry.Name())
+
+		if entry.IsDir() {
+			// attachments may have .md extension too
+			if entry.Name() == domain.AssetsDir {
+				continue
+			}
+
+			if err := s.walkDir(newPath, category, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+
+		if err := fn(category, newPath, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) readFile(path string) (string, error) {
+	file, err := s.fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	return string(content), err
+}
+
+// parseNote makes a note of file content. Only note body is used as content,
+// metadata is kept aside. Name is used as title for notes without one.
+func parseNote(name string, category domain.Category, content string) domain.Note {
+	title, meta, body := frontmatter.Decode(content)
+	if title == "" {
+		title = name
+	}
+
+	return domain.Note{
+		Title:    title,
+		Name:     name,
+		Content:  body,
+		Category: category,
+		Meta:     meta,
+	}
+}
+
+func (s *Storage) createFile(dir, filename string, content []byte) (string, error) {
+	path := path.Join(dir, filename)
+
+	file, err := s.fs.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	_, err = file.Write(content)
+	return path, err
+}
+
+// Note returns the note with given name from the category.
+func (s *Storage) Note(ctx context.Context, category domain.Category, name string) (domain.Note, error) {
+	const op = "storage.fs.Note"
+
+	content, err := s.readFile(domain.NotePath(category, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.Note{}, fmt.Errorf("%s: %w: %s", op, domain.ErrNoteNotFound, domain.NotePath(category, name))
+	}
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return parseNote(name, category, content), nil
+}
+
+// Delete removes the note with given name and its attachments from the category.
+func (s *Storage) Delete(ctx context.Context, category domain.Category, name string) error {
+	const op = "storage.fs.Delete"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notePath := domain.NotePath(category, name)
+	if !s.exists(notePath) {
+		return fmt.Errorf("%s: %w: %s", op, domain.ErrNoteNotFound, notePath)
+	}
+
+	paths := []string{notePath}
+
+	assetsDir := path.Join(string(category), domain.AssetPath(name, ""))
+
+	assets, err := s.fs.ReadDir(assetsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: read attachments error: %w", op, err)
+	}
+
+	for _, asset := range assets {
+		paths = append(paths, path.Join(assetsDir, asset.Name()))
+	}
+
+	for _, path := range paths {
+		if err := s.fs.Remove(path); err != nil {
+			return fmt.Errorf("%s: remove file error: %w", op, err)
+		}
+	}
+
+	_ = s.fs.Remove(assetsDir)
+
+	if err := s.changed(paths...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Sync does nothing, since notes are saved to the directory right away.
+func (s *Storage) Sync(ctx context.Context) error {
+	return nil
+}
+
+// Version returns hash of names, sizes and modification times of all notes,
+// so it changes when notes are changed, e.g. by other tools.
+func (s *Storage) Version() (string, error) {
+	const op = "storage.fs.Version"
+
+	hash := sha256.New()
+
+	err := s.walkNotes(func(_ domain.Category, path string, entry os.DirEntry) error {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(hash, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WithLock calls fn holding the storage lock, so notes aren't changed meanwhile,
+// e.g. while files are synced with a remote server. fn must not call methods of the storage.
+func (s *Storage) WithLock(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn()
+}
+
+// changed reports changed paths to the change handler. Caller must hold the lock.
+func (s *Storage) changed(paths ...string) error {
+	if s.onChange == nil {
+		return nil
+	}
+
+	return s.onChange(paths...)
+}
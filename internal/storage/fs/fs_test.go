@@ -0,0 +1,216 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/storage/fs"
+
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/osfs"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T, policy string) (*fs.Storage, string) {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "notes")
+
+	storage, err := fs.New(&config.StorageConfig{Type: config.StorageFS, Path: dir, CollisionPolicy: policy})
+	require.NoError(t, err)
+
+	return storage, dir
+}
+
+func TestAddWritesNote(t *testing.T) {
+	storage, dir := newStorage(t, config.CollisionSuffix)
+
+	note := domain.Note{
+		Title:       "Note",
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, outcome, err := storage.Add(context.Background(), note)
+	require.NoError(t, err)
+	require.Equal(t, domain.SaveCreated, outcome)
+
+	content, err := os.ReadFile(filepath.Join(dir, "go", "note.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "title: Note")
+
+	data, err := os.ReadFile(filepath.Join(dir, "go", domain.AssetPath("note", "image.png")))
+	require.NoError(t, err)
+	require.Equal(t, "png", string(data))
+
+	stored, err := storage.Note(context.Background(), "go", "note")
+	require.NoError(t, err)
+	require.Equal(t, "Note", stored.Title)
+}
+
+func TestAddCollisionPolicy(t *testing.T) {
+	testCases := []struct {
+		policy          string
+		expectedName    string
+		expectedOutcome domain.SaveOutcome
+		expectedErr     error
+	}{
+		{policy: config.CollisionSuffix, expectedName: "note-2", expectedOutcome: domain.SaveRenamed},
+		{policy: config.CollisionAppend, expectedName: "note", expectedOutcome: domain.SaveAppended},
+		{policy: config.CollisionReject, expectedErr: domain.ErrNoteExists},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			storage, _ := newStorage(t, tc.policy)
+			note := domain.Note{Title: "Note", Name: "note", Category: "go", Content: "text"}
+
+			_, _, err := storage.Add(context.Background(), note)
+			require.NoError(t, err)
+
+			stored, outcome, err := storage.Add(context.Background(), note)
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedOutcome, outcome)
+			require.Equal(t, tc.expectedName, stored.Name)
+		})
+	}
+}
+
+func TestMoveNote(t *testing.T) {
+	storage, dir := newStorage(t, config.CollisionSuffix)
+
+	note := domain.Note{
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err := storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	moved, err := storage.Move(context.Background(), "note", "go", "rust")
+	require.NoError(t, err)
+	require.Equal(t, domain.Category("rust"), moved.Category)
+
+	require.NoFileExists(t, filepath.Join(dir, "go", "note.md"))
+	require.FileExists(t, filepath.Join(dir, "rust", "note.md"))
+	require.FileExists(t, filepath.Join(dir, "rust", domain.AssetPath("note", "image.png")))
+
+	categories, err := storage.Categories(context.Background())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []domain.Category{"go", "rust"}, categories)
+}
+
+func TestMoveTakenAttachments(t *testing.T) {
+	storage, dir := newStorage(t, config.CollisionSuffix)
+
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	// only attachments are left of the note in the target category
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "rust", domain.AssetPath("note", "")), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rust", domain.AssetPath("note", "image.png")), []byte("png"), 0o644))
+
+	_, err = storage.Move(context.Background(), "note", "go", "rust")
+	require.ErrorIs(t, err, domain.ErrNoteExists)
+
+	require.FileExists(t, filepath.Join(dir, "go", "note.md"))
+	require.NoFileExists(t, filepath.Join(dir, "rust", "note.md"))
+}
+
+// failingFS fails to rename attachments.
+type failingFS struct {
+	billy.Filesystem
+}
+
+func (f failingFS) Rename(from, to string) error {
+	if strings.Contains(from, domain.AssetsDir) {
+		return errors.New("disk is full")
+	}
+
+	return f.Filesystem.Rename(from, to)
+}
+
+func TestMoveUndoneOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	var changed []string
+	storage := fs.NewFS(failingFS{Filesystem: osfs.New(dir)}, config.CollisionSuffix, func(paths ...string) error {
+		changed = append(changed, paths...)
+		return nil
+	})
+
+	note := domain.Note{
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err := storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	changed = nil
+
+	_, err = storage.Move(context.Background(), "note", "go", "rust")
+	require.ErrorContains(t, err, "disk is full")
+
+	// the note is kept together with its attachments
+	require.FileExists(t, filepath.Join(dir, "go", "note.md"))
+	require.FileExists(t, filepath.Join(dir, "go", domain.AssetPath("note", "image.png")))
+	require.NoFileExists(t, filepath.Join(dir, "rust", "note.md"))
+	require.NoDirExists(t, filepath.Join(dir, "rust", domain.AssetPath("note", "")))
+	require.Empty(t, changed)
+}
+
+func TestDeleteNote(t *testing.T) {
+	storage, dir := newStorage(t, config.CollisionSuffix)
+
+	note := domain.Note{
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err := storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.Delete(context.Background(), "go", "note"))
+
+	require.NoFileExists(t, filepath.Join(dir, "go", "note.md"))
+	require.NoDirExists(t, filepath.Join(dir, "go", domain.AssetPath("note", "")))
+
+	_, err = storage.Note(context.Background(), "go", "note")
+	require.ErrorIs(t, err, domain.ErrNoteNotFound)
+
+	err = storage.Delete(context.Background(), "go", "note")
+	require.ErrorIs(t, err, domain.ErrNoteNotFound)
+}
+
+func TestVersionChangesWithNotes(t *testing.T) {
+	storage, _ := newStorage(t, config.CollisionSuffix)
+
+	empty, err := storage.Version()
+	require.NoError(t, err)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	added, err := storage.Version()
+	require.NoError(t, err)
+	require.NotEqual(t, empty, added)
+
+	same, err := storage.Version()
+	require.NoError(t, err)
+	require.Equal(t, added, same)
+}
@@ -0,0 +1,223 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	"github.com/go-git/go-git/v6/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v6/plumbing/transport/ssh/knownhosts"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultUser    = "git"            // user for ssh and http auth, if user isn't configured
+	defaultSSHPort = "22"             // port of ssh remotes without port in URL
+	verifyTimeout  = 30 * time.Second // timeout of connection to verify ssh host key
+)
+
+// newAuth returns authentication method for the remote repository. If auth type
+// isn't configured, it's chosen by the URL scheme: SSH key or agent for ssh remotes,
+// basic auth for http remotes with a token and no authentication for others.
+// Nil is returned, if no authentication is needed.
+func newAuth(cfg *config.GitAuth, url string) (transport.AuthMethod, error) {
+	authType := cfg.Type
+	if authType == "" {
+		var err error
+		if authType, err = detectAuthType(cfg, url); err != nil {
+			return nil, err
+		}
+	}
+
+	user := cfg.User
+	if user == "" {
+		user = defaultUser
+	}
+
+	switch authType {
+	case config.AuthSSH:
+		keys, err := ssh.NewPublicKeys(user, []byte(cfg.Key), cfg.KeyPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		return withHostKeys(keys, &keys.HostKeyCallbackHelper, cfg, url)
+
+	case config.AuthSSHAgent:
+		agent, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, err
+		}
+
+		return withHostKeys(agent, &agent.HostKeyCallbackHelper, cfg, url)
+
+	case config.AuthHTTP:
+		return &http.BasicAuth{Username: user, Password: cfg.Token}, nil
+	case config.AuthToken:
+		return &http.TokenAuth{Token: cfg.Token}, nil
+	case config.AuthNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown auth type: %q", authType)
+	}
+}
+
+func detectAuthType(cfg *config.GitAuth, url string) (string, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	switch endpoint.Scheme {
+	case "ssh":
+		if cfg.Key == "" {
+			return config.AuthSSHAgent, nil
+		}
+		return config.AuthSSH, nil
+
+	case "http", "https":
+		if cfg.Token == "" {
+			return config.AuthNone, nil
+		}
+		return config.AuthHTTP, nil
+
+	default:
+		return config.AuthNone, nil
+	}
+}
+
+// hostKeyAuth sets host key algorithms of the ssh auth method explicitly,
+// since go-git looks them up in default known_hosts files otherwise.
+type hostKeyAuth struct {
+	ssh.AuthMethod
+	algorithms []string
+}
+
+// ClientConfig returns ssh client config of the underlying auth method with host key algorithms.
+func (a *hostKeyAuth) ClientConfig() (*gossh.ClientConfig, error) {
+	cfg, err := a.AuthMethod.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.HostKeyAlgorithms = a.algorithms
+
+	return cfg, nil
+}
+
+// withHostKeys sets up ssh host key verification of the auth method with configured
+// known hosts. Default known_hosts files are used, if known hosts aren't configured.
+// Helper is the one of the auth method, it looks up default files if callback isn't set.
+func withHostKeys(auth ssh.AuthMethod, helper *ssh.HostKeyCallbackHelper, cfg *config.GitAuth, url string) (ssh.AuthMethod, error) {
+	if cfg.InsecureIgnoreHostKey {
+		helper.HostKeyCallback = gossh.InsecureIgnoreHostKey()
+
+		return &hostKeyAuth{AuthMethod: auth, algorithms: gossh.SupportedAlgorithms().HostKeys}, nil
+	}
+
+	// default files are checked here to fail early, not on the first connection
+	if cfg.KnownHosts == "" && cfg.KnownHostsFile == "" {
+		if _, err := ssh.NewKnownHostsCallback(); err != nil {
+			return nil, fmt.Errorf("known hosts error, set auth.knownHosts, auth.knownHostsFile or auth.insecureIgnoreHostKey: %w", err)
+		}
+
+		return auth, nil
+	}
+
+	db, err := knownHostsDB(cfg.KnownHosts, cfg.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("known hosts error: %w", err)
+	}
+
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	port := endpoint.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	// unknown host is reported by the callback
+	algorithms := db.HostKeyAlgorithms(net.JoinHostPort(endpoint.Hostname(), port))
+	if len(algorithms) == 0 {
+		algorithms = gossh.SupportedAlgorithms().HostKeys
+	}
+
+	helper.HostKeyCallback = db.HostKeyCallback()
+
+	return &hostKeyAuth{AuthMethod: auth, algorithms: algorithms}, nil
+}
+
+// knownHostsDB reads known hosts from the content and from the file, if they aren't empty.
+// The content is written to a temporary file, since known hosts are read from files only.
+// The file is removed right away, since the database keeps hosts in memory.
+func knownHostsDB(content, path string) (*knownhosts.HostKeyDB, error) {
+	var files []string
+
+	if path != "" {
+		files = append(files, path)
+	}
+
+	if content != "" {
+		file, err := os.CreateTemp("", "known_hosts")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(file.Name())
+
+		_, err = file.WriteString(strings.TrimSpace(content) + "\n")
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file.Name())
+	}
+
+	return knownhosts.NewDB(files...)
+}
+
+// verifyHostKey connects to the remote to check its ssh host key, since an existing
+// repository is opened without connection. Other connection errors are ignored,
+// so the storage can be used offline.
+func verifyHostKey(repo *git.Repository, remoteName string, auth transport.AuthMethod) error {
+	if _, ok := auth.(ssh.AuthMethod); !ok {
+		return nil
+	}
+
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	_, err = remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+
+	return hostKeyError(err)
+}
+
+// hostKeyError returns a clear error, if err is caused by untrusted ssh host key.
+func hostKeyError(err error) error {
+	switch {
+	case knownhosts.IsHostKeyChanged(err):
+		return fmt.Errorf("ssh host key of the remote doesn't match known hosts: %w", err)
+	case knownhosts.IsHostUnknown(err):
+		return fmt.Errorf("ssh host key of the remote is unknown, add it to auth.knownHosts: %w", err)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,343 @@
+package git_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/storage/git"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v6/plumbing/transport/ssh"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newSSHServer starts a server, which only performs ssh handshake with given host key,
+// and returns its address.
+func newSSHServer(t *testing.T, hostKey ssh.Signer) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	cfg := &ssh.ServerConfig{NoClientAuth: true}
+	cfg.AddHostKey(hostKey)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = ssh.NewServerConn(conn, cfg)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func newSigner(t *testing.T) (ssh.Signer, []byte) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(key, "")
+	require.NoError(t, err)
+
+	return signer, pem.EncodeToMemory(block)
+}
+
+func TestNewHostKeyVerification(t *testing.T) {
+	hostKey, _ := newSigner(t)
+	otherKey, clientKey := newSigner(t)
+
+	addr := newSSHServer(t, hostKey)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	knownHost := func(key ssh.Signer) string {
+		return fmt.Sprintf("[%s]:%s %s", host, port, ssh.MarshalAuthorizedKey(key.PublicKey()))
+	}
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownHost(hostKey)), 0o644))
+
+	testCases := []struct {
+		name           string
+		knownHosts     string
+		knownHostsFile string
+		expectedErr    string
+	}{
+		{
+			name:       "key matches",
+			knownHosts: knownHost(hostKey),
+		},
+		{
+			name:           "key matches in file",
+			knownHostsFile: knownHostsFile,
+		},
+		{
+			name:        "key mismatch",
+			knownHosts:  knownHost(otherKey),
+			expectedErr: "ssh host key of the remote doesn't match known hosts",
+		},
+		{
+			name:        "unknown host",
+			knownHosts:  "example.com " + string(ssh.MarshalAuthorizedKey(otherKey.PublicKey())),
+			expectedErr: "ssh host key of the remote is unknown",
+		},
+		{
+			name:           "missing known hosts file",
+			knownHostsFile: filepath.Join(t.TempDir(), "missing"),
+			expectedErr:    "known hosts error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &config.GitRepository{
+				URL:        fmt.Sprintf("ssh://git@%s/notes.git", addr),
+				Path:       filepath.Join(t.TempDir(), "notes"),
+				Branch:     branch,
+				RemoteName: "origin",
+				Auth:       config.GitAuth{Key: string(clientKey), KnownHosts: tc.knownHosts, KnownHostsFile: tc.knownHostsFile},
+				BufSize:    1,
+				Retry:      config.RetryConfig{MaxAttempts: 1},
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := git.New(cfg, config.CollisionSuffix)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				// the server doesn't serve the repository, so the clone fails after the handshake anyway
+				require.Error(t, err)
+
+				if tc.expectedErr == "" {
+					require.NotContains(t, err.Error(), "host key")
+					require.NotContains(t, err.Error(), "known hosts")
+					return
+				}
+
+				require.ErrorContains(t, err, tc.expectedErr)
+			case <-time.After(10 * time.Second):
+				require.FailNow(t, "git.New hangs")
+			}
+		})
+	}
+}
+
+func TestNewKnownHostsContentNotKeptOnDisk(t *testing.T) {
+	hostKey, clientKey := newSigner(t)
+
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	cfg := &config.GitAuth{
+		Key:        string(clientKey),
+		KnownHosts: "example.com " + string(ssh.MarshalAuthorizedKey(hostKey.PublicKey())),
+	}
+
+	_, err := git.NewAuth(cfg, "ssh://git@example.com/notes.git")
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestDetectAuthType(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		auth        config.GitAuth
+		expected    string
+		expectedErr bool
+	}{
+		{name: "ssh URL with key", url: "ssh://git@example.com/notes.git", auth: config.GitAuth{Key: "key"}, expected: config.AuthSSH},
+		{name: "scp-like URL with key", url: "git@example.com:user/notes.git", auth: config.GitAuth{Key: "key"}, expected: config.AuthSSH},
+		{name: "ssh URL without key", url: "ssh://git@example.com/notes.git", expected: config.AuthSSHAgent},
+		{name: "https URL with token", url: "https://example.com/notes.git", auth: config.GitAuth{Token: "token"}, expected: config.AuthHTTP},
+		{name: "http URL with token", url: "http://example.com/notes.git", auth: config.GitAuth{Token: "token"}, expected: config.AuthHTTP},
+		{name: "https URL without token", url: "https://example.com/notes.git", expected: config.AuthNone},
+		{name: "local path", url: "/srv/notes.git", auth: config.GitAuth{Key: "key", Token: "token"}, expected: config.AuthNone},
+		{name: "invalid URL", url: "https://example.com:port/notes.git", expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			authType, err := git.DetectAuthType(&tc.auth, tc.url)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, authType)
+		})
+	}
+}
+
+// startSSHAgent serves an ssh agent with no keys and points SSH_AUTH_SOCK to it.
+func startSSHAgent(t *testing.T) {
+	t.Helper()
+
+	// unix socket path is limited in length, so the default temp dir of the test may not fit
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	sock := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	keyring := agent.NewKeyring()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	t.Setenv("SSH_AUTH_SOCK", sock)
+}
+
+func TestNewAuth(t *testing.T) {
+	_, clientKey := newSigner(t)
+	startSSHAgent(t)
+
+	const sshURL = "ssh://git@example.com/notes.git"
+
+	testCases := []struct {
+		name        string
+		url         string
+		auth        config.GitAuth
+		check       func(t *testing.T, auth transport.AuthMethod)
+		expectedErr string
+	}{
+		{
+			name: "ssh key",
+			url:  sshURL,
+			auth: config.GitAuth{Type: config.AuthSSH, Key: string(clientKey), InsecureIgnoreHostKey: true},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, gitssh.PublicKeysName, auth.Name())
+			},
+		},
+		{
+			name:        "invalid ssh key",
+			url:         sshURL,
+			auth:        config.GitAuth{Type: config.AuthSSH, Key: "key", InsecureIgnoreHostKey: true},
+			expectedErr: "ssh",
+		},
+		{
+			name: "ssh agent",
+			url:  sshURL,
+			auth: config.GitAuth{Type: config.AuthSSHAgent, InsecureIgnoreHostKey: true},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, gitssh.PublicKeysCallbackName, auth.Name())
+			},
+		},
+		{
+			name: "ssh agent detected by URL",
+			url:  sshURL,
+			auth: config.GitAuth{InsecureIgnoreHostKey: true},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, gitssh.PublicKeysCallbackName, auth.Name())
+			},
+		},
+		{
+			name: "http with default user",
+			url:  "https://example.com/notes.git",
+			auth: config.GitAuth{Type: config.AuthHTTP, Token: "token"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, &http.BasicAuth{Username: "git", Password: "token"}, auth)
+			},
+		},
+		{
+			name: "http detected by URL",
+			url:  "https://example.com/notes.git",
+			auth: config.GitAuth{User: "user", Token: "token"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, &http.BasicAuth{Username: "user", Password: "token"}, auth)
+			},
+		},
+		{
+			name: "token",
+			url:  "https://example.com/notes.git",
+			auth: config.GitAuth{Type: config.AuthToken, Token: "token"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Equal(t, &http.TokenAuth{Token: "token"}, auth)
+			},
+		},
+		{
+			name: "none",
+			url:  "https://example.com/notes.git",
+			auth: config.GitAuth{Type: config.AuthNone, Token: "token"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Nil(t, auth)
+			},
+		},
+		{
+			name: "none detected by URL",
+			url:  "/srv/notes.git",
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				require.Nil(t, auth)
+			},
+		},
+		{
+			name:        "unknown type",
+			url:         sshURL,
+			auth:        config.GitAuth{Type: "kerberos"},
+			expectedErr: "unknown auth type",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth, err := git.NewAuth(&tc.auth, tc.url)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			tc.check(t, auth)
+		})
+	}
+}
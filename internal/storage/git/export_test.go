@@ -0,0 +1,8 @@
+package git
+
+// Exported for tests.
+var (
+	Backoff        = backoff
+	NewAuth        = newAuth
+	DetectAuthType = detectAuthType
+)
@@ -2,66 +2,100 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"embed"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"path"
-	"strings"
+	"os"
+	"slices"
 	"sync"
 	"text/template"
 	"time"
 
 	"protomorphine/tg-notes/internal/config"
-	"protomorphine/tg-notes/internal/domain"
 	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/fs"
 
 	"github.com/go-git/go-git/v6"
 	gitCfg "github.com/go-git/go-git/v6/config"
 	"github.com/go-git/go-git/v6/plumbing"
 	"github.com/go-git/go-git/v6/plumbing/object"
-	"github.com/go-git/go-git/v6/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v6/plumbing/transport"
 )
 
-//go:embed resources
-var templates embed.FS
-
-// GitStorage represents a Git-backed storage for notes.
+// GitStorage represents a Git-backed storage for notes. Notes are stored in the worktree
+// as files and saved to the Git repository by the Processor.
 type GitStorage struct {
+	*fs.Storage
+
 	worktree *git.Worktree
 	repo     *git.Repository
-	pubKey   *ssh.PublicKeys
+	auth     transport.AuthMethod
 
-	config *config.GitRepository
+	config    *config.GitRepository
+	commitMsg *template.Template
+	signing   *signing // commits aren't signed, if nil
 
-	mu sync.Mutex
+	mu     sync.Mutex
+	saveMu sync.Mutex // serializes saves, since Sync may be called while the Processor runs
 
-	buf       []string
+	buf       []string // paths changed since the last save
+	journal   *journal // on-disk copy of paths, which aren't pushed yet
 	bufFullCh chan struct{}
+
+	observer       Observer                    // operations with the remote aren't observed, if nil
+	onRemoteChange storage.RemoteChangeHandler // remote changes aren't reported, if nil
+}
+
+// Observer records operations with the remote repository, e.g. to export metrics.
+type Observer interface {
+	ObserveRemote(operation string, duration time.Duration, err error)
 }
 
+// Operations with the remote repository reported to the Observer.
+const (
+	OperationPull = "pull" // fetch and merge of remote changes
+	OperationPush = "push"
+)
+
 // New creates a new instance of GitStorage. It clones the repository if it doesn't exist
 // and sets up the worktree.
-func New(cfg *config.GitRepository) (*GitStorage, error) {
+func New(cfg *config.GitRepository, collisionPolicy string) (*GitStorage, error) {
 	const op = "storage.git.New"
 
-	publicKeys, err := ssh.NewPublicKeys("git", []byte(cfg.Auth.Key), cfg.Auth.KeyPassword)
+	commitMsg, err := newCommitTemplate(cfg.CommitMessage)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: commit message template error: %w", op, err)
+	}
+
+	signing, err := newSigning(&cfg.Signing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: signing key error: %w", op, err)
+	}
+
+	auth, err := newAuth(&cfg.Auth, cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: auth error: %w", op, err)
 	}
 
 	repo, err := git.PlainOpenWithOptions(cfg.Path, &git.PlainOpenOptions{DetectDotGit: true})
 	if errors.Is(err, git.ErrRepositoryNotExists) {
 		repo, err = git.PlainClone(cfg.Path, &git.CloneOptions{
-			Auth: publicKeys,
+			Auth: auth,
 			URL:  cfg.URL,
 		})
 		if err != nil {
+			if keyErr := hostKeyError(err); keyErr != nil {
+				err = keyErr
+			}
+
 			return nil, fmt.Errorf("%s: clone error: %w", op, err)
 		}
+	} else if err == nil {
+		if err := verifyHostKey(repo, cfg.RemoteName, auth); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	worktree, err := repo.Worktree()
@@ -82,50 +116,88 @@ func New(cfg *config.GitRepository) (*GitStorage, error) {
 		Merge:  localBranch,
 	})
 
-	// thx a lot for @ajschmidt8
-	// https://github.com/go-git/go-git/issues/279#issuecomment-816359799
-	newReference := plumbing.NewSymbolicReference(localBranch, remoteBranch)
-	if err := repo.Storer.SetReference(newReference); err != nil {
+	create, err := setUpBranch(repo, localBranch, remoteBranch)
+	if err != nil {
 		return nil, fmt.Errorf("%s: set reference error: %w", op, err)
 	}
 
-	_, err = repo.Branch(cfg.Branch)
-
 	checkoutOpts := &git.CheckoutOptions{
 		Branch: localBranch,
-		Create: errors.Is(err, git.ErrBranchNotFound),
+		Create: create,
+		Keep:   true, // notes, which weren't committed before restart, are kept
 	}
 
 	if err := worktree.Checkout(checkoutOpts); err != nil {
 		return nil, fmt.Errorf("%s: checkout error %w", op, err)
 	}
 
-	return &GitStorage{
+	journal, pending, err := openJournal(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage := &GitStorage{
 		config:    cfg,
+		commitMsg: commitMsg,
+		signing:   signing,
 		repo:      repo,
 		worktree:  worktree,
-		pubKey:    publicKeys,
-		buf:       make([]string, 0, cfg.BufSize),
+		auth:      auth,
+		buf:       make([]string, 0, max(cfg.BufSize, len(pending))),
+		journal:   journal,
 		bufFullCh: make(chan struct{}, 1),
-	}, nil
+	}
+
+	storage.Storage = fs.NewFS(worktree.Filesystem, collisionPolicy, storage.track)
+
+	// changes, which weren't pushed before restart, are saved first
+	if len(pending) > 0 {
+		storage.buf = append(storage.buf, pending...)
+		storage.bufFullCh <- struct{}{}
+	}
+
+	return storage, nil
 }
 
-// Add adds a new note to the storage. The note is buffered and saved
-// to the Git repository by the Processor.
-func (g *GitStorage) Add(ctx context.Context, note domain.Note) error {
-	const op = "storage.git.Add"
+// setUpBranch points the local branch to the remote one, if the local branch doesn't exist.
+// Earlier versions made the local branch a symbolic reference to the remote one, which lost
+// local commits on fetch, so such reference is replaced with a regular one. Reports whether
+// the branch should be created from HEAD, because the remote branch doesn't exist too.
+func setUpBranch(repo *git.Repository, local, remote plumbing.ReferenceName) (bool, error) {
+	ref, err := repo.Reference(local, false)
+	if err == nil && ref.Type() == plumbing.HashReference {
+		return false, nil
+	}
+
+	if err != nil && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, err
+	}
+
+	// the symbolic reference resolves to the remote branch too
+	target, err := repo.Reference(remote, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
 
+	return false, repo.Storer.SetReference(plumbing.NewHashReference(local, target.Hash()))
+}
+
+// track records changed paths to the journal and buffers them. Paths aren't buffered,
+// if the journal write fails, so changes of the failed save aren't pushed.
+func (g *GitStorage) track(paths ...string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	path, err := g.createFile(string(note.Category), note.Title+".md", note.Content)
-	if err != nil {
-		return fmt.Errorf("%s: file save error: %w", op, err)
+	if err := g.journal.append(paths...); err != nil {
+		return fmt.Errorf("journal write error: %w", err)
 	}
 
-	g.buf = append(g.buf, path)
+	g.buf = append(g.buf, paths...)
 
-	if len(g.buf) == g.config.BufSize {
+	if len(g.buf) >= g.config.BufSize {
 		select {
 		case g.bufFullCh <- struct{}{}:
 		default:
@@ -135,77 +207,82 @@ func (g *GitStorage) Add(ctx context.Context, note domain.Note) error {
 	return nil
 }
 
-func (g *GitStorage) Notes(ctx context.Context) ([]domain.Note, error) {
-	const op = "storage.git.Notes"
+// SetObserver sets the observer of operations with the remote repository.
+// It must be called before the Processor is started.
+func (g *GitStorage) SetObserver(observer Observer) {
+	g.observer = observer
+}
 
-	var notes []domain.Note
+// SetRemoteChangeHandler sets the handler, which is called when remote changes are written
+// to the worktree or local changes are moved to a side branch. It must be called before
+// the Processor is started.
+func (g *GitStorage) SetRemoteChangeHandler(handler storage.RemoteChangeHandler) {
+	g.onRemoteChange = handler
+}
 
-	rootEntries, err := g.worktree.Filesystem.ReadDir("/")
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to read root directory: %w", op, err)
+// observe reports the operation started at start to the observer, if it's set.
+func (g *GitStorage) observe(operation string, start time.Time, err error) {
+	if g.observer != nil {
+		g.observer.ObserveRemote(operation, time.Since(start), err)
 	}
+}
 
-	for _, entry := range rootEntries {
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
-		}
+// Pending returns count of changed paths, which aren't saved to the remote repository yet.
+func (g *GitStorage) Pending() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-		category := domain.Category(entry.Name())
-		if err := g.readNotesRecursive(entry.Name(), category, &notes); err != nil {
-			return nil, fmt.Errorf("%s: failed to read notes for category %s: %w", op, category, err)
-		}
+	return len(g.buf)
+}
+
+// Close closes the journal. Pending changes are kept on disk and saved after restart.
+func (g *GitStorage) Close() error {
+	const op = "storage.git.Close"
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.journal.close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return notes, nil
+	return nil
 }
 
-func (g *GitStorage) readNotesRecursive(currentPath string, category domain.Category, notes *[]domain.Note) error {
-	const op = "storage.git.readNotesRecursive"
+// Version returns hash of the current HEAD commit.
+func (g *GitStorage) Version() (string, error) {
+	const op = "storage.git.Version"
 
-	entries, err := g.worktree.Filesystem.ReadDir(currentPath)
+	ref, err := g.repo.Head()
 	if err != nil {
-		return fmt.Errorf("%s: failed to read directory %s: %w", op, currentPath, err)
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	for _, entry := range entries {
-		newPath := path.Join(currentPath, entry.Name())
-
-		if entry.IsDir() {
-			if err := g.readNotesRecursive(newPath, category, notes); err != nil {
-				return err
-			}
-			continue
-		}
-
-		if !strings.HasSuffix(entry.Name(), ".md") {
-			continue
-		}
+	return ref.Hash().String(), nil
+}
 
-		file, err := g.worktree.Filesystem.Open(newPath)
-		if err != nil {
-			return fmt.Errorf("%s: failed to open note file %s: %w", op, newPath, err)
-		}
+// Sync commits and pushes pending changes right away, without retries.
+// It waits for the save made by the Processor, if any. *storage.ConflictError
+// with the side branch is returned, if changes are pushed there.
+func (g *GitStorage) Sync(ctx context.Context) error {
+	const op = "storage.git.Sync"
 
-		content, err := io.ReadAll(file)
-		if err != nil {
-			_ = file.Close()
-			return fmt.Errorf("%s: failed to read note content from %s: %w", op, newPath, err)
-		}
-		_ = file.Close()
+	_, sideBranch, err := g.handlePendingNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-		*notes = append(*notes, domain.Note{
-			Category: category,
-			Title:    strings.TrimSuffix(entry.Name(), ".md"),
-			Content:  string(content),
-		})
+	if sideBranch != "" {
+		return fmt.Errorf("%s: %w", op, &storage.ConflictError{Locations: []string{sideBranch}})
 	}
 
 	return nil
 }
 
 // Processor starts a background goroutine that periodically commits and pushes
-// buffered notes to the remote Git repository.
-func (g *GitStorage) Processor(ctx context.Context, logger *slog.Logger) {
+// buffered notes to the remote Git repository. onConflict is called with the side branch name,
+// when local changes can't be merged with the remote branch automatically.
+func (g *GitStorage) Processor(ctx context.Context, logger *slog.Logger, onConflict storage.ConflictHandler) {
 	const op = "storage.git.Processor"
 	logger = logger.With(log.Op(op))
 
@@ -225,7 +302,7 @@ func (g *GitStorage) Processor(ctx context.Context, logger *slog.Logger) {
 			}
 
 			logger.Debug("start updating remote storage, reason: buffer is full")
-			g.triggerUpdate(ctx, logger)
+			g.triggerUpdate(ctx, logger, onConflict)
 
 		case _, ok := <-ticker:
 			if !ok {
@@ -233,140 +310,312 @@ func (g *GitStorage) Processor(ctx context.Context, logger *slog.Logger) {
 			}
 
 			logger.Debug("start updating remote storage, reason: timer")
-			g.triggerUpdate(ctx, logger)
+			g.triggerUpdate(ctx, logger, onConflict)
 		}
 	}
 }
 
-func (g *GitStorage) triggerUpdate(ctx context.Context, logger *slog.Logger) {
-	saved, err := g.handlePendingNotes(ctx)
+// triggerUpdate saves pending notes, retrying failed attempts with backoff.
+// If all attempts fail, notes are kept pending until the next update.
+func (g *GitStorage) triggerUpdate(ctx context.Context, logger *slog.Logger, onConflict storage.ConflictHandler) {
+	retry := &g.config.Retry
+
+	for attempt := 1; ; attempt++ {
+		saved, sideBranch, err := g.handlePendingNotes(ctx)
+		if err == nil {
+			if sideBranch != "" {
+				logger.Warn("notes conflict with remote changes, pushed to the side branch",
+					slog.Int("count", saved), slog.String("branch", sideBranch))
+				onConflict(ctx, sideBranch)
+				return
+			}
+
+			if saved == 0 {
+				logger.Debug("no new notes to save")
+				return
+			}
+
+			logger.Info("notes saved successfully", slog.Int("count", saved))
+			return
+		}
+
+		if attempt >= retry.MaxAttempts || ctx.Err() != nil {
+			unpushed, _ := g.Unpushed()
+			logger.Error("error while handling pending notes, notes are kept until the next update",
+				log.Err(err), slog.Int("attempts", attempt), slog.Int("unpushed", unpushed))
+			return
+		}
+
+		delay := backoff(retry, attempt)
+		logger.Warn("error while handling pending notes, retrying",
+			log.Err(err), slog.Int("attempt", attempt), slog.String("delay", delay.String()))
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Unpushed returns count of local commits, which aren't pushed to the remote repository yet.
+// Commits reachable from the remote branch, e.g. remote commits merged into the local branch, are pushed already.
+func (g *GitStorage) Unpushed() (int, error) {
+	const op = "storage.git.Unpushed"
+
+	local, remote, err := g.branches()
 	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if local == nil {
+		return 0, nil
+	}
+
+	pushed := make(map[plumbing.Hash]bool)
 
-		logger.Error("error while handling pending notes", log.Err(err))
-		return
+	if remote != nil {
+		err := object.NewCommitPreorderIter(remote, nil, nil).ForEach(func(commit *object.Commit) error {
+			pushed[commit.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	if saved == 0 {
-		logger.Debug("no new notes to save")
-		return
+	var count int
+
+	// the walk doesn't go past pushed commits
+	iter := object.NewCommitPreorderIter(local, pushed, nil)
+	err = iter.ForEach(func(*object.Commit) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	logger.Info("notes saved successfully", slog.Int("count", saved))
+	return count, nil
 }
 
-func (g *GitStorage) handlePendingNotes(ctx context.Context) (int, error) {
+// handlePendingNotes commits buffered changes, merges them with remote changes and pushes.
+// Returns count of saved changes and the name of the side branch, if changes were pushed there.
+func (g *GitStorage) handlePendingNotes(ctx context.Context) (int, string, error) {
 	const op = "storage.git.handlePendingNotes"
 
 	if err := ctx.Err(); err != nil {
-		return 0, fmt.Errorf("%s: context err: %w", op, err)
+		return 0, "", fmt.Errorf("%s: context err: %w", op, err)
+	}
+
+	g.saveMu.Lock()
+	defer g.saveMu.Unlock()
+
+	if g.Pending() == 0 {
+		return 0, "", nil
+	}
+
+	var buf []string
+
+	// changes are committed before the fetch, so they are kept in the repository,
+	// even if the remote is unavailable
+	err := g.Storage.WithLock(func() error {
+		var err error
+		buf, err = g.commitBuffered(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		sideBranch string
+		moved      bool
+	)
+
+	// the storage isn't locked during the fetch, so notes are saved meanwhile
+	start := time.Now()
+	err = g.fetch(ctx)
+	if err == nil {
+		// notes saved during the fetch are committed too, so they are compared with remote
+		// changes and aren't overwritten by them, when the worktree is updated
+		err = g.Storage.WithLock(func() error {
+			saved, err := g.commitBuffered(ctx)
+			if err != nil {
+				return err
+			}
+
+			buf = append(buf, saved...)
+
+			sideBranch, moved, err = g.syncWorktree(ctx)
+			return err
+		})
+	}
+	g.observe(OperationPull, start, err)
+
+	if err != nil {
+		g.requeue(buf)
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if moved && g.onRemoteChange != nil {
+		g.onRemoteChange(ctx)
+	}
+
+	start = time.Now()
+	err = g.push(ctx, plumbing.NewBranchReferenceName(g.config.Branch))
+	g.observe(OperationPush, start, err)
+
+	if err != nil {
+		g.requeue(buf)
+		return 0, "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	if len(g.buf) == 0 {
-		g.mu.Unlock()
-		return 0, nil
+	// paths changed during the push are kept for the next update
+	if err := g.journal.reset(g.buf); err != nil {
+		return len(buf), sideBranch, fmt.Errorf("%s: journal reset error: %w", op, err)
 	}
 
-	buf := make([]string, len(g.buf))
-	copy(buf, g.buf)
+	return len(buf), sideBranch, nil
+}
+
+// requeue buffers paths of the failed save again. Paths are still in the journal,
+// so they are saved on the next update.
+func (g *GitStorage) requeue(buf []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.buf = append(buf, g.buf...)
+}
 
+// commitBuffered takes buffered paths and commits their changes. Paths are buffered again,
+// if the commit fails. Returns taken paths. Caller must hold the storage lock.
+func (g *GitStorage) commitBuffered(ctx context.Context) ([]string, error) {
+	const op = "storage.git.commitBuffered"
+
+	g.mu.Lock()
+	buf := slices.Clone(g.buf)
 	g.buf = g.buf[:0]
 	g.mu.Unlock()
 
-	if err := g.prepareStorage(ctx); err != nil {
-		return 0, fmt.Errorf("%s: prepare storage error: %w", op, err)
-	}
-
 	for _, path := range buf {
 		if _, err := g.worktree.Add(path); err != nil {
-			return 0, fmt.Errorf("%s: add file %s to worktree error: %w", op, path, err)
+			// a file created and removed or moved away before the commit, e.g. the old path
+			// of a note moved to another category, isn't committed yet, so there is nothing to stage
+			if _, err := g.worktree.Filesystem.Lstat(path); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			g.requeue(buf)
+			return nil, fmt.Errorf("%s: add file %s to worktree error: %w", op, path, err)
 		}
 	}
 
-	notesCount := len(buf)
-
-	if err := g.save(ctx, notesCount); err != nil {
-		return 0, fmt.Errorf("%s: save notes error: %w", op, err)
+	if err := g.commit(ctx, buf); err != nil {
+		g.requeue(buf)
+		return nil, fmt.Errorf("%s: commit error: %w", op, err)
 	}
 
-	return notesCount, nil
+	return buf, nil
 }
 
-func (g *GitStorage) createFile(dir, filename, content string) (string, error) {
-	path := path.Join(dir, filename)
+// syncWorktree integrates fetched remote changes like sync. Reports whether HEAD moved,
+// i.e. the worktree got remote changes or was reset after the side branch.
+// Caller must hold the storage lock.
+func (g *GitStorage) syncWorktree(ctx context.Context) (string, bool, error) {
+	const op = "storage.git.syncWorktree"
 
-	file, err := g.worktree.Filesystem.Create(path)
+	before, err := g.Version()
 	if err != nil {
-		return "", err
+		return "", false, fmt.Errorf("%s: %w", op, err)
 	}
-	defer file.Close()
-
-	_, err = file.Write([]byte(content))
-	return path, err
-}
 
-func (g *GitStorage) save(ctx context.Context, notesCount int) error {
-	const op = "storage.git.save"
+	sideBranch, err := g.sync(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: sync error: %w", op, err)
+	}
 
-	commitMsg, err := createCommitMsg(notesCount)
+	after, err := g.Version()
 	if err != nil {
-		return fmt.Errorf("%s: error while generating commit message: %w", op, err)
+		return "", false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	commitOpts := &git.CommitOptions{
-		Committer: &object.Signature{
-			Name: g.config.Committer.Name,
-			When: time.Now(),
-		},
+	return sideBranch, after != before, nil
+}
+
+// commit commits staged changes of given paths. Changes may be committed already,
+// if the previous push failed.
+func (g *GitStorage) commit(ctx context.Context, paths []string) error {
+	status, err := g.worktree.Status()
+	if err != nil {
+		return fmt.Errorf("error while getting worktree status: %w", err)
 	}
 
-	if _, err := g.worktree.Commit(commitMsg, commitOpts); err != nil {
-		return fmt.Errorf("%s: error while commiting changes: %w", op, err)
+	if !staged(status) {
+		return nil
 	}
 
-	err = g.repo.PushContext(ctx, &git.PushOptions{
-		Auth:       g.pubKey,
-		RemoteName: g.config.RemoteName,
-	})
+	commitMsg, err := g.commitMessage(ctx, paths)
 	if err != nil {
-		return fmt.Errorf("%s: error while pushing changes to remote: %w", op, err)
+		return fmt.Errorf("error while generating commit message: %w", err)
+	}
+
+	if _, err := g.worktree.Commit(commitMsg, g.commitOptions()); err != nil {
+		return fmt.Errorf("error while commiting changes: %w", err)
 	}
 
 	return nil
 }
 
-func createCommitMsg(notesCount int) (string, error) {
-	tmpl, err := template.ParseFS(templates, "resources/commit_message.tmpl")
-	if err != nil {
-		return "", err
-	}
+// commitOptions returns options with configured committer, author and signing key.
+// Committer is the author too, if the author isn't configured.
+func (g *GitStorage) commitOptions() *git.CommitOptions {
+	now := time.Now()
 
-	type templateVars struct {
-		NotesCount int
-		Time       time.Time
+	committer := &object.Signature{
+		Name:  g.config.Committer.Name,
+		Email: g.config.Committer.Email,
+		When:  now,
 	}
 
-	vars := templateVars{
-		NotesCount: notesCount,
-		Time:       time.Now(),
+	author := committer
+	if g.config.Author.Name != "" {
+		author = &object.Signature{
+			Name:  g.config.Author.Name,
+			Email: g.config.Author.Email,
+			When:  now,
+		}
 	}
 
-	buf := &bytes.Buffer{}
-	if err := tmpl.Execute(buf, vars); err != nil {
-		return "", err
-	}
+	opts := &git.CommitOptions{Committer: committer, Author: author}
+	g.signing.apply(opts)
 
-	return buf.String(), nil
+	return opts
 }
 
-func (g *GitStorage) prepareStorage(ctx context.Context) error {
-	const op = "storage.git.prepareStorage"
-
-	pullOpts := &git.PullOptions{RemoteName: g.config.RemoteName, Auth: g.pubKey, Force: true}
-	if err := g.worktree.PullContext(ctx, pullOpts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-		return fmt.Errorf("%s: error while pull changes from remote: %w", op, err)
+// push pushes the local branch to the remote branch with the same name.
+func (g *GitStorage) push(ctx context.Context, branch plumbing.ReferenceName) error {
+	err := g.repo.PushContext(ctx, &git.PushOptions{
+		Auth:       g.auth,
+		RemoteName: g.config.RemoteName,
+		RefSpecs:   []gitCfg.RefSpec{gitCfg.RefSpec(branch + ":" + branch)},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error while pushing changes to remote: %w", err)
 	}
 
 	return nil
 }
+
+// staged reports whether there are staged changes in the worktree.
+func staged(status git.Status) bool {
+	for _, file := range status {
+		if file.Staging != git.Unmodified && file.Staging != git.Untracked {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,654 @@
+package git_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	tgstorage "protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/git"
+
+	gogit "github.com/go-git/go-git/v6"
+	gitconfig "github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+const branch = "master"
+
+// remote is a bare repository with a clone to change it like from another machine.
+type remote struct {
+	url   string
+	clone *gogit.Repository
+	dir   string
+}
+
+func newRemote(t *testing.T) *remote {
+	t.Helper()
+
+	// commits need author, which is taken from the global git config
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n\tname = test\n\temail = test@example.com\n"), 0o644))
+
+	url := filepath.Join(t.TempDir(), "remote.git")
+	_, err := gogit.PlainInit(url, true)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	clone, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	_, err = clone.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{url}})
+	require.NoError(t, err)
+
+	r := &remote{url: url, clone: clone, dir: dir}
+	r.commit(t, "README.md", "notes")
+
+	return r
+}
+
+// commit commits the file in the clone and pushes it to the remote.
+func (r *remote) commit(t *testing.T, name, content string) {
+	t.Helper()
+
+	worktree, err := r.clone.Worktree()
+	require.NoError(t, err)
+
+	if head, err := r.clone.Head(); err == nil {
+		err = worktree.Pull(&gogit.PullOptions{RemoteName: "origin", ReferenceName: head.Name()})
+		if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(r.dir, name)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, name), []byte(content), 0o644))
+
+	_, err = worktree.Add(name)
+	require.NoError(t, err)
+
+	_, err = worktree.Commit("change "+name, &gogit.CommitOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, r.clone.Push(&gogit.PushOptions{RemoteName: "origin"}))
+}
+
+// head returns the last commit of the remote branch.
+func (r *remote) head(t *testing.T, branch string) *object.Commit {
+	t.Helper()
+
+	repo, err := gogit.PlainOpen(r.url)
+	require.NoError(t, err)
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	require.NoError(t, err)
+
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+
+	return commit
+}
+
+// file returns content of the file in the remote branch or false if there is no such file.
+func (r *remote) file(t *testing.T, branch, name string) (string, bool) {
+	t.Helper()
+
+	repo, err := gogit.PlainOpen(r.url)
+	require.NoError(t, err)
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return "", false
+	}
+
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+
+	file, err := commit.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return "", false
+	}
+	require.NoError(t, err)
+
+	content, err := file.Contents()
+	require.NoError(t, err)
+
+	return content, true
+}
+
+func newConfig(t *testing.T, r *remote) *config.GitRepository {
+	t.Helper()
+
+	return &config.GitRepository{
+		URL:             r.url,
+		Path:            filepath.Join(t.TempDir(), "notes"),
+		Branch:          branch,
+		RemoteName:      "origin",
+		Committer:       config.Signature{Name: "tg-notes", Email: "bot@example.com"},
+		BufSize:         1,
+		UpdateDuratiion: time.Hour,
+		Retry:           config.RetryConfig{MaxAttempts: 1},
+	}
+}
+
+func newStorage(t *testing.T, r *remote, policy string) *git.GitStorage {
+	t.Helper()
+
+	storage, err := git.New(newConfig(t, r), policy)
+	require.NoError(t, err)
+
+	return storage
+}
+
+// startProcessor starts the storage processor and returns channel of side branches.
+func startProcessor(t *testing.T, storage *git.GitStorage) <-chan string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	conflicts := make(chan string, 1)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	go storage.Processor(ctx, logger, func(_ context.Context, branch string) {
+		conflicts <- branch
+	})
+
+	return conflicts
+}
+
+func TestAddPushesNote(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+	startProcessor(t, storage)
+
+	_, _, err := storage.Add(context.Background(), domain.Note{Title: "Note", Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		content, ok := r.file(t, branch, "go/note.md")
+		return ok && content == "---\ntitle: Note\n---\ntext"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// the remote gets the commit before the push returns and updates the remote-tracking
+	// branch, so the commit may be reported unpushed for a moment after the file is seen
+	require.Eventually(t, func() bool {
+		unpushed, err := storage.Unpushed()
+		return err == nil && unpushed == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// observer records operations with the remote repository.
+type observer struct {
+	mu         sync.Mutex
+	operations []string
+	failed     int
+}
+
+func (o *observer) ObserveRemote(operation string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err != nil {
+		o.failed++
+		return
+	}
+
+	o.operations = append(o.operations, operation)
+}
+
+func (o *observer) failures() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.failed
+}
+
+func TestSyncPushesPendingNotes(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.BufSize = 10
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	observer := &observer{}
+	storage.SetObserver(observer)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+	require.Equal(t, 1, storage.Pending())
+
+	_, ok := r.file(t, branch, "go/note.md")
+	require.False(t, ok)
+
+	require.NoError(t, storage.Sync(context.Background()))
+	require.Zero(t, storage.Pending())
+	require.Equal(t, []string{git.OperationPull, git.OperationPush}, observer.operations)
+
+	content, ok := r.file(t, branch, "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "text", content)
+
+	// pending notes are pushed, so nothing is left after restart
+	restarted, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	unpushed, err := restarted.Unpushed()
+	require.NoError(t, err)
+	require.Zero(t, unpushed)
+	require.Zero(t, restarted.Pending())
+}
+
+func TestPendingNotesReplayedAfterRestart(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.BufSize = 10
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	// the process dies before the note is pushed
+	restarted, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+	require.Equal(t, 1, restarted.Pending())
+
+	require.NoError(t, restarted.Sync(context.Background()))
+
+	content, ok := r.file(t, branch, "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "text", content)
+}
+
+func TestPendingNotesKeptAfterFailedSync(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.BufSize = 10
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	// the remote is unavailable
+	unavailable := r.url + ".unavailable"
+	require.NoError(t, os.Rename(r.url, unavailable))
+
+	require.Error(t, storage.Sync(context.Background()))
+	require.Equal(t, 1, storage.Pending())
+
+	// the note is committed, but not pushed
+	unpushed, err := storage.Unpushed()
+	require.NoError(t, err)
+	require.Equal(t, 1, unpushed)
+
+	restarted, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+	require.Equal(t, 1, restarted.Pending())
+
+	require.NoError(t, os.Rename(unavailable, r.url))
+	require.NoError(t, restarted.Sync(context.Background()))
+
+	_, ok := r.file(t, branch, "go/note.md")
+	require.True(t, ok)
+
+	unpushed, err = restarted.Unpushed()
+	require.NoError(t, err)
+	require.Zero(t, unpushed)
+}
+
+func TestProcessorRetriesUpToMaxAttempts(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.Retry = config.RetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	observer := &observer{}
+	storage.SetObserver(observer)
+
+	require.NoError(t, os.RemoveAll(r.url))
+	startProcessor(t, storage)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return observer.failures() == cfg.Retry.MaxAttempts
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// no more attempts are made until the next update
+	require.Never(t, func() bool {
+		return observer.failures() > cfg.Retry.MaxAttempts
+	}, 200*time.Millisecond, 10*time.Millisecond)
+	require.Equal(t, 1, storage.Pending())
+}
+
+func TestAddJournalWriteError(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.BufSize = 10
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	// the journal can't be written after it's closed
+	require.NoError(t, storage.Close())
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.Error(t, err)
+	require.Zero(t, storage.Pending())
+}
+
+func TestAddCollisionPolicy(t *testing.T) {
+	testCases := []struct {
+		policy          string
+		expectedName    string
+		expectedOutcome domain.SaveOutcome
+		expectedErr     error
+	}{
+		{policy: config.CollisionSuffix, expectedName: "note-2", expectedOutcome: domain.SaveRenamed},
+		{policy: config.CollisionAppend, expectedName: "note", expectedOutcome: domain.SaveAppended},
+		{policy: config.CollisionReject, expectedErr: domain.ErrNoteExists},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			storage := newStorage(t, newRemote(t), tc.policy)
+			note := domain.Note{Title: "Note", Name: "note", Category: "go", Content: "text"}
+
+			_, outcome, err := storage.Add(context.Background(), note)
+			require.NoError(t, err)
+			require.Equal(t, domain.SaveCreated, outcome)
+
+			stored, outcome, err := storage.Add(context.Background(), note)
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedOutcome, outcome)
+			require.Equal(t, tc.expectedName, stored.Name)
+		})
+	}
+}
+
+// reportRemoteChanges sets the remote change handler and returns channel, which receives a value per call.
+func reportRemoteChanges(storage *git.GitStorage) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	storage.SetRemoteChangeHandler(func(context.Context) {
+		changes <- struct{}{}
+	})
+
+	return changes
+}
+
+func TestSyncFastForwardsRemoteChanges(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+	changes := reportRemoteChanges(storage)
+
+	r.commit(t, "go/remote.md", "remote")
+
+	// the note is removed before the commit, so nothing is committed and the local branch is behind
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "draft", Category: "go", Content: "draft"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Delete(context.Background(), "go", "draft"))
+	require.NoError(t, storage.Sync(context.Background()))
+
+	require.Len(t, changes, 1)
+
+	note, err := storage.Note(context.Background(), "go", "remote")
+	require.NoError(t, err)
+	require.Equal(t, "remote", note.Content)
+
+	unpushed, err := storage.Unpushed()
+	require.NoError(t, err)
+	require.Zero(t, unpushed)
+}
+
+func TestSyncMergesSameChanges(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+
+	r.commit(t, "go/note.md", "text")
+
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Sync(context.Background()))
+
+	content, ok := r.file(t, branch, "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "text", content)
+}
+
+func TestSyncMergesRemoteChanges(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+	changes := reportRemoteChanges(storage)
+
+	r.commit(t, "go/remote.md", "remote")
+	startProcessor(t, storage)
+
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := r.file(t, branch, "go/note.md")
+		return ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	content, ok := r.file(t, branch, "go/remote.md")
+	require.True(t, ok)
+	require.Equal(t, "remote", content)
+
+	// the remote note is written to the worktree
+	require.Len(t, changes, 1)
+
+	note, err := storage.Note(context.Background(), "go", "remote")
+	require.NoError(t, err)
+	require.Equal(t, "remote", note.Content)
+}
+
+func TestSyncConflictPushesSideBranch(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionAppend)
+	changes := reportRemoteChanges(storage)
+	conflicts := startProcessor(t, storage)
+
+	note := domain.Note{Name: "note", Category: "go", Content: "text"}
+
+	_, _, err := storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := r.file(t, branch, "go/note.md")
+		return ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	r.commit(t, "go/note.md", "edited elsewhere")
+
+	note.Content = "appended"
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	var side string
+	select {
+	case side = <-conflicts:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "conflict wasn't reported")
+	}
+
+	content, ok := r.file(t, side, "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "text\n\nappended", content)
+
+	content, ok = r.file(t, branch, "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "edited elsewhere", content)
+
+	// the worktree is reset to the remote branch
+	require.Len(t, changes, 1)
+
+	stored, err := storage.Note(context.Background(), "go", "note")
+	require.NoError(t, err)
+	require.Equal(t, "edited elsewhere", stored.Content)
+}
+
+func TestCommitMessageTemplate(t *testing.T) {
+	r := newRemote(t)
+
+	cfg := newConfig(t, r)
+	cfg.Author = config.Signature{Name: "Alice", Email: "alice@example.com"}
+	cfg.CommitMessage = "{{.NotesCount}} notes{{range .Notes}}\n{{.Category}}: {{.Title}} ({{.Path}}){{end}}"
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+	startProcessor(t, storage)
+
+	note := domain.Note{
+		Title:       "Release plan",
+		Name:        "release-plan",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := r.file(t, branch, "go/release-plan.md")
+		return ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	commit := r.head(t, branch)
+	require.Equal(t, "1 notes\ngo: Release plan (go/release-plan.md)", commit.Message)
+	require.Equal(t, "Alice", commit.Author.Name)
+	require.Equal(t, "alice@example.com", commit.Author.Email)
+	require.Equal(t, "tg-notes", commit.Committer.Name)
+	require.Equal(t, "bot@example.com", commit.Committer.Email)
+}
+
+func TestNewInvalidCommitMessageTemplate(t *testing.T) {
+	cfg := newConfig(t, newRemote(t))
+	cfg.CommitMessage = "{{.NotesCount"
+
+	_, err := git.New(cfg, config.CollisionSuffix)
+	require.ErrorContains(t, err, "commit message template error")
+}
+
+func TestSyncKeepsNoteSavedDuringSync(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+
+	r.commit(t, "go/note.md", "remote")
+
+	// the draft makes the sync fetch and update the worktree
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "draft", Category: "go", Content: "draft"})
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		saved   domain.Note
+		saveErr error
+	)
+
+	wg.Go(func() {
+		saved, _, saveErr = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "local"})
+	})
+
+	var conflict *tgstorage.ConflictError
+	if err := storage.Sync(context.Background()); err != nil {
+		require.ErrorAs(t, err, &conflict)
+	}
+
+	wg.Wait()
+	require.NoError(t, saveErr)
+
+	// the note is saved either before the worktree is updated and conflicts with the remote one,
+	// or after that and is renamed, but it's never overwritten by the remote note
+	require.NoError(t, storage.Sync(context.Background()))
+
+	if saved.Name == "note" {
+		require.NotNil(t, conflict, "the note isn't pushed to a side branch")
+
+		content, ok := r.file(t, conflict.Locations[0], "go/note.md")
+		require.True(t, ok)
+		require.Equal(t, "local", content)
+		return
+	}
+
+	content, ok := r.file(t, branch, domain.NotePath("go", saved.Name))
+	require.True(t, ok)
+	require.Equal(t, "local", content)
+}
+
+func TestSyncReportsSideBranch(t *testing.T) {
+	r := newRemote(t)
+	storage := newStorage(t, r, config.CollisionSuffix)
+
+	r.commit(t, "go/note.md", "edited elsewhere")
+
+	_, _, err := storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	var conflict *tgstorage.ConflictError
+	require.ErrorAs(t, storage.Sync(context.Background()), &conflict)
+	require.NoError(t, conflict.Err)
+	require.Len(t, conflict.Locations, 1)
+
+	content, ok := r.file(t, conflict.Locations[0], "go/note.md")
+	require.True(t, ok)
+	require.Equal(t, "text", content)
+}
+
+func TestUnpushedSkipsMergedRemoteCommits(t *testing.T) {
+	r := newRemote(t)
+	cfg := newConfig(t, r)
+
+	storage, err := git.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	r.commit(t, "go/remote.md", "remote")
+	remoteHead := r.head(t, branch)
+
+	_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+	require.NoError(t, err)
+
+	// the note is committed and merged with the remote commit
+	require.NoError(t, storage.Sync(context.Background()))
+
+	unpushed, err := storage.Unpushed()
+	require.NoError(t, err)
+	require.Zero(t, unpushed)
+
+	// the remote branch is known before the push, like if the push failed
+	repo, err := gogit.PlainOpen(cfg.Path)
+	require.NoError(t, err)
+
+	ref := plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", branch), remoteHead.Hash)
+	require.NoError(t, repo.Storer.SetReference(ref))
+
+	// the note commit and the merge commit, but not the merged remote commit and commits before it
+	unpushed, err = storage.Unpushed()
+	require.NoError(t, err)
+	require.Equal(t, 2, unpushed)
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// journalFile is the name of the journal inside .git directory, so it isn't tracked.
+const journalFile = "tg-notes-pending"
+
+// journal is an on-disk list of paths changed in the worktree, but not pushed yet.
+// It survives restarts, so changes aren't lost if the process dies before push.
+type journal struct {
+	path string
+	file *os.File
+}
+
+// openJournal opens the journal in the repository and returns paths recorded in it.
+func openJournal(repoPath string) (*journal, []string, error) {
+	const op = "storage.git.openJournal"
+
+	j := &journal{path: filepath.Join(repoPath, ".git", journalFile)}
+
+	paths, err := j.read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := j.open(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return j, paths, nil
+}
+
+// append records paths to the journal and flushes them to disk.
+func (j *journal) append(paths ...string) error {
+	var b strings.Builder
+	for _, path := range paths {
+		b.WriteString(path + "\n")
+	}
+
+	if _, err := j.file.WriteString(b.String()); err != nil {
+		return err
+	}
+
+	return j.file.Sync()
+}
+
+// reset atomically replaces journal content with given paths.
+func (j *journal) reset(paths []string) error {
+	tmp := j.path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if _, err := file.WriteString(path + "\n"); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+
+	// the old file was replaced, so new entries should go to the new one
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+
+	return j.open()
+}
+
+// close closes the journal file. Recorded paths are kept on disk.
+func (j *journal) close() error {
+	return j.file.Close()
+}
+
+func (j *journal) open() error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	j.file = file
+	return nil
+}
+
+// read returns unique paths from the journal in order of appearance.
+func (j *journal) read() ([]string, error) {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var paths []string
+	seen := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		path := scanner.Text()
+
+		// the last line may be incomplete if the process died while writing it,
+		// such path doesn't exist in the worktree and is skipped while staging
+		if _, ok := seen[path]; ok || path == "" {
+			continue
+		}
+
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+
+	return paths, scanner.Err()
+}
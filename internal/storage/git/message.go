@@ -0,0 +1,89 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"protomorphine/tg-notes/internal/domain"
+)
+
+//go:embed resources
+var templates embed.FS
+
+// commitData is available in the commit message template.
+type commitData struct {
+	Notes      []commitNote // notes changed by the commit
+	NotesCount int          // count of changed notes
+	Time       time.Time    // commit time
+}
+
+// commitNote is a note changed by the commit.
+type commitNote struct {
+	Title    string
+	Category string
+	Path     string
+	Deleted  bool // note was deleted or moved to another category
+}
+
+// newCommitTemplate parses the commit message template. The embedded one is used, if text is empty.
+func newCommitTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return template.ParseFS(templates, "resources/commit_message.tmpl")
+	}
+
+	return template.New("commit_message").Parse(text)
+}
+
+// commitMessage executes the commit message template for notes among changed paths.
+func (g *GitStorage) commitMessage(ctx context.Context, paths []string) (string, error) {
+	data := commitData{
+		Notes: g.changedNotes(ctx, paths),
+		Time:  time.Now(),
+	}
+	data.NotesCount = len(data.Notes)
+
+	buf := &bytes.Buffer{}
+	if err := g.commitMsg.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// changedNotes returns notes among changed paths in order of change, attachments are skipped.
+func (g *GitStorage) changedNotes(ctx context.Context, paths []string) []commitNote {
+	var notes []commitNote
+
+	for _, notePath := range paths {
+		category, file, ok := strings.Cut(notePath, "/")
+		if !ok || path.Ext(file) != ".md" || slices.Contains(strings.Split(file, "/"), domain.AssetsDir) {
+			continue
+		}
+
+		if slices.ContainsFunc(notes, func(n commitNote) bool { return n.Path == notePath }) {
+			continue
+		}
+
+		name := strings.TrimSuffix(file, ".md")
+		note := commitNote{Title: name, Category: category, Path: notePath}
+
+		stored, err := g.Note(ctx, domain.Category(category), name)
+		switch {
+		case errors.Is(err, domain.ErrNoteNotFound):
+			note.Deleted = true
+		case err == nil:
+			note.Title = stored.Title
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes
+}
@@ -0,0 +1,26 @@
+package git
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+)
+
+// backoff returns delay before the given retry attempt, starting from 1.
+// Delay doubles with every attempt up to the max delay and deviates randomly by jitter.
+func backoff(cfg *config.RetryConfig, attempt int) time.Duration {
+	delay := cfg.InitialDelay
+	for i := 1; i < attempt && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, cfg.MaxDelay)
+
+	if cfg.Jitter > 0 {
+		deviation := cfg.Jitter * float64(delay)
+		delay += time.Duration(deviation * (2*rand.Float64() - 1))
+	}
+
+	return delay
+}
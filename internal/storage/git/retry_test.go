@@ -0,0 +1,41 @@
+package git_test
+
+import (
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/storage/git"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := &config.RetryConfig{InitialDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Second},
+		{attempt: 2, expected: 2 * time.Second},
+		{attempt: 3, expected: 4 * time.Second},
+		{attempt: 4, expected: 8 * time.Second},
+		{attempt: 5, expected: 10 * time.Second},
+		{attempt: 100, expected: 10 * time.Second},
+	}
+
+	for _, tc := range tests {
+		require.Equal(t, tc.expected, git.Backoff(cfg, tc.attempt), "attempt %d", tc.attempt)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	cfg := &config.RetryConfig{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+
+	for range 100 {
+		delay := git.Backoff(cfg, 2)
+		require.GreaterOrEqual(t, delay, time.Second)
+		require.LessOrEqual(t, delay, 3*time.Second)
+	}
+}
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"protomorphine/tg-notes/internal/config"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v6"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshSigNamespace = "git"    // namespace of SSH signatures, which git verifies
+	sshSigHash      = "sha512" // hash of the signed message
+	sshSigLineLen   = 70       // line length of armored SSH signature
+)
+
+// signing holds the key to sign commits. Only one of the fields is set.
+type signing struct {
+	key    *openpgp.Entity // OpenPGP key, go-git signs with it natively
+	signer git.Signer      // signer of other formats
+}
+
+// newSigning parses the signing key. Nil is returned, if commits aren't signed.
+func newSigning(cfg *config.SigningConfig) (*signing, error) {
+	if cfg.Key == "" {
+		return nil, nil
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = config.SignSSH
+		if strings.Contains(cfg.Key, "BEGIN PGP PRIVATE KEY BLOCK") {
+			format = config.SignOpenPGP
+		}
+	}
+
+	switch format {
+	case config.SignOpenPGP:
+		key, err := openPGPKey(cfg.Key, cfg.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("OpenPGP key error: %w", err)
+		}
+
+		return &signing{key: key}, nil
+
+	case config.SignSSH:
+		signer, err := newSSHSigner(cfg.Key, cfg.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("SSH key error: %w", err)
+		}
+
+		return &signing{signer: signer}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown signing format: %q", format)
+	}
+}
+
+// apply sets up commit options to sign the commit.
+func (s *signing) apply(opts *git.CommitOptions) {
+	if s == nil {
+		return
+	}
+
+	opts.SignKey = s.key
+	opts.Signer = s.signer
+}
+
+// openPGPKey returns the first key of the armored key ring, decrypted with the passphrase.
+func openPGPKey(armored, passphrase string) (*openpgp.Entity, error) {
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyRing) == 0 {
+		return nil, fmt.Errorf("no keys found")
+	}
+
+	key := keyRing[0]
+	if key.PrivateKey == nil {
+		return nil, fmt.Errorf("private key is required")
+	}
+
+	if key.PrivateKey.Encrypted {
+		if err := key.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// sshSigner makes armored SSH signatures in the format of ssh-keygen -Y sign,
+// see PROTOCOL.sshsig in OpenSSH.
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+func newSSHSigner(key, passphrase string) (*sshSigner, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+
+	if passphrase == "" {
+		signer, err = ssh.ParsePrivateKey([]byte(key))
+	} else {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshSigner{signer: signer}, nil
+}
+
+// Sign returns armored SSH signature of the message.
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	hash := sha512.New()
+	if _, err := io.Copy(hash, message); err != nil {
+		return nil, err
+	}
+
+	signed := ssh.Marshal(struct {
+		Magic     [6]byte
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Hash      string
+	}{
+		Magic:     [6]byte([]byte("SSHSIG")),
+		Namespace: sshSigNamespace,
+		HashAlg:   sshSigHash,
+		Hash:      string(hash.Sum(nil)),
+	})
+
+	var (
+		sig *ssh.Signature
+		err error
+	)
+
+	// SHA-1 signatures of RSA keys are rejected by git
+	algSigner, ok := s.signer.(ssh.AlgorithmSigner)
+	if ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = algSigner.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.signer.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blob := ssh.Marshal(struct {
+		Magic     [6]byte
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}{
+		Magic:     [6]byte([]byte("SSHSIG")),
+		Version:   1,
+		PublicKey: string(s.signer.PublicKey().Marshal()),
+		Namespace: sshSigNamespace,
+		HashAlg:   sshSigHash,
+		Signature: string(ssh.Marshal(sig)),
+	})
+
+	return armorSSHSignature(blob), nil
+}
+
+func armorSSHSignature(blob []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b bytes.Buffer
+
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > sshSigLineLen {
+		b.WriteString(encoded[:sshSigLineLen] + "\n")
+		encoded = encoded[sshSigLineLen:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString("-----END SSH SIGNATURE-----\n")
+
+	return b.Bytes()
+}
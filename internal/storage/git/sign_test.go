@@ -0,0 +1,156 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/storage/git"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// newOpenPGPKey returns armored private key encrypted with the passphrase and armored public key.
+func newOpenPGPKey(t *testing.T, passphrase string) (string, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("tg-notes", "", "bot@example.com", nil)
+	require.NoError(t, err)
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+
+	var private bytes.Buffer
+	w, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+
+	return private.String(), public.String()
+}
+
+// verifySSHSignature checks the commit signature with ssh-keygen, like git does.
+func verifySSHSignature(t *testing.T, commit *object.Commit, key ssh.PublicKey) {
+	t.Helper()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen isn't installed")
+	}
+
+	dir := t.TempDir()
+
+	signers := filepath.Join(dir, "allowed_signers")
+	require.NoError(t, os.WriteFile(signers, []byte("bot@example.com "+string(ssh.MarshalAuthorizedKey(key))), 0o644))
+
+	signature := filepath.Join(dir, "commit.sig")
+	require.NoError(t, os.WriteFile(signature, []byte(commit.PGPSignature), 0o644))
+
+	encoded := &plumbing.MemoryObject{}
+	require.NoError(t, commit.EncodeWithoutSignature(encoded))
+
+	reader, err := encoded.Reader()
+	require.NoError(t, err)
+
+	payload, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	cmd := exec.Command("ssh-keygen", "-Y", "verify", "-f", signers, "-I", "bot@example.com", "-n", "git", "-s", signature)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestSignedCommits(t *testing.T) {
+	privatePGP, publicPGP := newOpenPGPKey(t, "secret")
+	sshSigner, sshKey := newSigner(t)
+
+	testCases := []struct {
+		name    string
+		signing config.SigningConfig
+		verify  func(t *testing.T, commit *object.Commit)
+	}{
+		{
+			name:    "openpgp",
+			signing: config.SigningConfig{Key: privatePGP, Passphrase: "secret"},
+			verify: func(t *testing.T, commit *object.Commit) {
+				require.True(t, strings.HasPrefix(commit.PGPSignature, "-----BEGIN PGP SIGNATURE-----"))
+
+				_, err := commit.Verify(publicPGP)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "ssh",
+			signing: config.SigningConfig{Format: config.SignSSH, Key: string(sshKey)},
+			verify: func(t *testing.T, commit *object.Commit) {
+				require.True(t, strings.HasPrefix(commit.PGPSignature, "-----BEGIN SSH SIGNATURE-----"))
+
+				verifySSHSignature(t, commit, sshSigner.PublicKey())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRemote(t)
+
+			cfg := newConfig(t, r)
+			cfg.Signing = tc.signing
+
+			storage, err := git.New(cfg, config.CollisionSuffix)
+			require.NoError(t, err)
+			startProcessor(t, storage)
+
+			_, _, err = storage.Add(context.Background(), domain.Note{Name: "note", Category: "go", Content: "text"})
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				_, ok := r.file(t, branch, "go/note.md")
+				return ok
+			}, 5*time.Second, 50*time.Millisecond)
+
+			tc.verify(t, r.head(t, branch))
+		})
+	}
+}
+
+func TestNewInvalidSigningKey(t *testing.T) {
+	_, publicPGP := newOpenPGPKey(t, "secret")
+
+	testCases := []struct {
+		name    string
+		signing config.SigningConfig
+	}{
+		{name: "public openpgp key", signing: config.SigningConfig{Format: config.SignOpenPGP, Key: publicPGP}},
+		{name: "invalid ssh key", signing: config.SigningConfig{Format: config.SignSSH, Key: "not a key"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newConfig(t, newRemote(t))
+			cfg.Signing = tc.signing
+
+			_, err := git.New(cfg, config.CollisionSuffix)
+			require.ErrorContains(t, err, "signing key error")
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// fetch fetches changes of the remote branch.
+func (g *GitStorage) fetch(ctx context.Context) error {
+	err := g.repo.FetchContext(ctx, &git.FetchOptions{RemoteName: g.config.RemoteName, Auth: g.auth})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error while fetching changes from remote: %w", err)
+	}
+
+	return nil
+}
+
+// sync integrates fetched remote changes into the local branch. The local branch is fast-forwarded,
+// if it's behind the remote one. Diverged branches are merged, if local and remote commits
+// change different files. Otherwise local commits are pushed to a side branch, which name
+// is returned, and the local branch is reset to the remote one. Caller must hold the storage
+// lock and commit buffered changes first, so the worktree has no changes, which aren't compared
+// with remote ones.
+func (g *GitStorage) sync(ctx context.Context) (string, error) {
+	const op = "storage.git.sync"
+
+	local, remote, err := g.branches()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the remote branch is created on push
+	if local == nil || remote == nil || local.Hash == remote.Hash {
+		return "", nil
+	}
+
+	bases, err := local.MergeBase(remote)
+	if err != nil {
+		return "", fmt.Errorf("%s: merge base error: %w", op, err)
+	}
+
+	if len(bases) == 0 {
+		return g.pushSideBranch(ctx, local, remote)
+	}
+
+	base := bases[0]
+
+	switch base.Hash {
+	case remote.Hash:
+		// local branch is ahead
+		return "", nil
+
+	case local.Hash:
+		if err := g.checkoutChanges(local, remote); err != nil {
+			return "", fmt.Errorf("%s: fast-forward error: %w", op, err)
+		}
+
+		return "", g.setBranch(remote.Hash)
+	}
+
+	merged, err := g.merge(local, remote, base)
+	if err != nil {
+		return "", fmt.Errorf("%s: merge error: %w", op, err)
+	}
+
+	if !merged {
+		return g.pushSideBranch(ctx, local, remote)
+	}
+
+	return "", nil
+}
+
+// branches returns the last commits of the local branch and of the remote one.
+// Commit is nil, if the branch doesn't exist yet.
+func (g *GitStorage) branches() (*object.Commit, *object.Commit, error) {
+	local, err := g.branchCommit(plumbing.NewBranchReferenceName(g.config.Branch))
+	if err != nil {
+		return nil, nil, fmt.Errorf("local branch error: %w", err)
+	}
+
+	remote, err := g.branchCommit(plumbing.NewRemoteReferenceName(g.config.RemoteName, g.config.Branch))
+	if err != nil {
+		return nil, nil, fmt.Errorf("remote branch error: %w", err)
+	}
+
+	return local, remote, nil
+}
+
+func (g *GitStorage) branchCommit(name plumbing.ReferenceName) (*object.Commit, error) {
+	ref, err := g.repo.Reference(name, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return g.repo.CommitObject(ref.Hash())
+}
+
+// merge makes a merge commit of local and remote commits, if they change different files.
+// Files changed by remote commits are written to the worktree before the commit.
+// Reports whether commits were merged.
+func (g *GitStorage) merge(local, remote, base *object.Commit) (bool, error) {
+	localChanges, err := changedFiles(base, local)
+	if err != nil {
+		return false, err
+	}
+
+	remoteChanges, err := changedFiles(base, remote)
+	if err != nil {
+		return false, err
+	}
+
+	for path, hash := range remoteChanges {
+		if localHash, ok := localChanges[path]; ok && localHash != hash {
+			return false, nil
+		}
+	}
+
+	if err := g.checkoutChanges(base, remote); err != nil {
+		return false, err
+	}
+
+	opts := g.commitOptions()
+	opts.Parents = []plumbing.Hash{local.Hash, remote.Hash}
+	// local and remote commits may make the same changes, so the merge doesn't change the tree
+	opts.AllowEmptyCommits = true
+
+	msg := fmt.Sprintf("Merge remote-tracking branch '%s/%s'", g.config.RemoteName, g.config.Branch)
+	if _, err := g.worktree.Commit(msg, opts); err != nil {
+		return false, fmt.Errorf("error while commiting merge: %w", err)
+	}
+
+	return true, nil
+}
+
+// pushSideBranch pushes local commits to a new branch and resets the local branch
+// to the remote one. Returns name of the new branch. The storage lock is held during the push,
+// so notes aren't saved to the worktree, until it's reset.
+func (g *GitStorage) pushSideBranch(ctx context.Context, local, remote *object.Commit) (string, error) {
+	const op = "storage.git.pushSideBranch"
+
+	name := fmt.Sprintf("%s-conflict-%s", g.config.Branch, time.Now().Format("20060102-150405"))
+	branch := plumbing.NewBranchReferenceName(name)
+
+	if err := g.repo.Storer.SetReference(plumbing.NewHashReference(branch, local.Hash)); err != nil {
+		return "", fmt.Errorf("%s: create branch error: %w", op, err)
+	}
+
+	if err := g.push(ctx, branch); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := g.checkoutChanges(local, remote); err != nil {
+		return "", fmt.Errorf("%s: reset error: %w", op, err)
+	}
+
+	if err := g.setBranch(remote.Hash); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return name, nil
+}
+
+// setBranch points the local branch to given commit.
+func (g *GitStorage) setBranch(hash plumbing.Hash) error {
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(g.config.Branch), hash)
+	if err := g.repo.Storer.SetReference(ref); err != nil {
+		return fmt.Errorf("error while setting branch: %w", err)
+	}
+
+	return nil
+}
+
+// checkoutChanges writes files changed between commits to the worktree and stages them.
+// Other files aren't touched, unlike worktree reset. Caller must hold the storage lock.
+func (g *GitStorage) checkoutChanges(from, to *object.Commit) error {
+	changes, err := changedFiles(from, to)
+	if err != nil {
+		return err
+	}
+
+	tree, err := to.Tree()
+	if err != nil {
+		return err
+	}
+
+	for path, hash := range changes {
+		if hash.IsZero() {
+			if _, err := g.worktree.Remove(path); err != nil {
+				return fmt.Errorf("error while removing file %s: %w", path, err)
+			}
+			continue
+		}
+
+		if err := g.checkoutFile(tree, path); err != nil {
+			return fmt.Errorf("error while writing file %s: %w", path, err)
+		}
+
+		if _, err := g.worktree.Add(path); err != nil {
+			return fmt.Errorf("error while adding file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *GitStorage) checkoutFile(tree *object.Tree, name string) error {
+	file, err := tree.File(name)
+	if err != nil {
+		return err
+	}
+
+	mode, err := file.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := g.worktree.Filesystem.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	dst, err := g.worktree.Filesystem.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, reader)
+	return err
+}
+
+// changedFiles returns files changed between commits with hashes of their new content.
+// Hash of removed files is zero.
+func changedFiles(from, to *object.Commit) (map[string]plumbing.Hash, error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]plumbing.Hash, len(changes))
+
+	for _, change := range changes {
+		if change.From.Name != "" {
+			files[change.From.Name] = plumbing.ZeroHash
+		}
+
+		if change.To.Name != "" {
+			files[change.To.Name] = change.To.TreeEntry.Hash
+		}
+	}
+
+	return files, nil
+}
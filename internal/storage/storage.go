@@ -0,0 +1,58 @@
+// Package storage defines the interface of notes storage backends.
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"protomorphine/tg-notes/internal/domain"
+)
+
+// Storage is an interface of notes storage backend.
+type Storage interface {
+	// Add adds a new note and returns the stored note.
+	Add(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error)
+	// Note returns the note with given name from the category.
+	Note(ctx context.Context, category domain.Category, name string) (domain.Note, error)
+	// Notes returns all stored notes.
+	Notes(ctx context.Context) ([]domain.Note, error)
+	// Categories returns all categories presented in the storage.
+	Categories(ctx context.Context) ([]domain.Category, error)
+	// Move moves the note to another category and returns the moved note.
+	Move(ctx context.Context, name string, from, to domain.Category) (domain.Note, error)
+	// Delete removes the note with given name from the category.
+	Delete(ctx context.Context, category domain.Category, name string) error
+	// Sync saves pending changes, e.g. to a remote repository. *ConflictError is returned,
+	// if some changes conflict with remote ones and are saved aside.
+	Sync(ctx context.Context) error
+	// Version returns version of stored notes, which changes when notes are changed.
+	Version() (string, error)
+}
+
+// ConflictHandler is called when local changes conflict with remote ones and are saved
+// aside to the given location, e.g. a side branch, to be merged manually.
+type ConflictHandler func(ctx context.Context, location string)
+
+// RemoteChangeHandler is called when stored notes were changed by remote changes,
+// e.g. pulled from a remote repository, so data built from notes can be rebuilt.
+type RemoteChangeHandler func(ctx context.Context)
+
+// ConflictError is returned by Sync, when local changes conflict with remote ones and are saved
+// aside to Locations, like reported to ConflictHandler by background processors.
+type ConflictError struct {
+	Locations []string
+	Err       error // error, which stopped saving other changes, nil if they are saved
+}
+
+func (e *ConflictError) Error() string {
+	msg := "changes conflict with remote ones, saved aside to " + strings.Join(e.Locations, ", ")
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,258 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var (
+	errNotFound           = errors.New("resource not found")
+	errPreconditionFailed = errors.New("resource was changed on the server")
+)
+
+// propfindBody requests only properties needed to tell files from directories and detect changes.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getetag/></d:prop></d:propfind>`
+
+// resource is a file or a directory on the server.
+type resource struct {
+	path string // path relative to the base URL, without leading slash
+	dir  bool
+	etag string
+}
+
+// multistatus is a PROPFIND response.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ETag string `xml:"DAV: getetag"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// client is a minimal WebDAV client, which supports only requests needed by the storage.
+// Paths are relative to the base URL.
+type client struct {
+	http     *http.Client
+	base     *url.URL
+	user     string
+	password string
+}
+
+func newClient(rawURL, user, password string, httpClient *http.Client) (*client, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV URL: %w", err)
+	}
+
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	return &client{http: httpClient, base: base, user: user, password: password}, nil
+}
+
+// list returns the resource itself and its direct children.
+func (c *client) list(ctx context.Context, dir string) ([]resource, error) {
+	header := http.Header{"Depth": {"1"}, "Content-Type": {"application/xml"}}
+
+	resp, err := c.do(ctx, "PROPFIND", dir, strings.NewReader(propfindBody), header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, statusError(resp)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("invalid PROPFIND response: %w", err)
+	}
+
+	resources := make([]resource, 0, len(ms.Responses))
+
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, fmt.Errorf("invalid href %q: %w", r.Href, err)
+		}
+
+		res := resource{path: strings.Trim(strings.TrimPrefix(href.Path, c.base.Path), "/")}
+
+		for _, propstat := range r.Propstat {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+
+			res.dir = propstat.Prop.ResourceType.Collection != nil
+			res.etag = propstat.Prop.ETag
+		}
+
+		resources = append(resources, res)
+	}
+
+	return resources, nil
+}
+
+// etag returns the current ETag of the file.
+func (c *client) etag(ctx context.Context, name string) (string, error) {
+	resources, err := c.list(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	for _, res := range resources {
+		if res.path == name {
+			return res.etag, nil
+		}
+	}
+
+	return "", errNotFound
+}
+
+// get returns content of the file and its ETag.
+func (c *client) get(ctx context.Context, name string) ([]byte, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", statusError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// put uploads the file, if it wasn't changed on the server since it had given ETag.
+// Empty ETag means the file shouldn't exist on the server. Returns the new ETag.
+func (c *client) put(ctx context.Context, name string, data []byte, etag string) (string, error) {
+	header := http.Header{}
+	if etag == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", etag)
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, name, bytes.NewReader(data), header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return resp.Header.Get("ETag"), nil
+	default:
+		return "", statusError(resp)
+	}
+}
+
+// delete removes the file, if it wasn't changed on the server since it had given ETag.
+// Missing file isn't an error.
+func (c *client) delete(ctx context.Context, name, etag string) error {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+
+	resp, err := c.do(ctx, http.MethodDelete, name, nil, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return statusError(resp)
+	}
+}
+
+// mkdirAll creates the directory with all parents, which don't exist.
+func (c *client) mkdirAll(ctx context.Context, dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+
+	if err := c.mkdirAll(ctx, path.Dir(dir)); err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, "MKCOL", dir+"/", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	// 405 is returned for existing directories
+	case http.StatusCreated, http.StatusMethodNotAllowed:
+		return nil
+	default:
+		return statusError(resp)
+	}
+}
+
+func (c *client) do(ctx context.Context, method, name string, body io.Reader, header http.Header) (*http.Response, error) {
+	u := c.base.JoinPath(name)
+	if strings.HasSuffix(name, "/") && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s request error: %w", method, name, err)
+	}
+
+	return resp, nil
+}
+
+// statusError returns error of the unexpected response status.
+func statusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return errNotFound
+	case http.StatusPreconditionFailed:
+		return errPreconditionFailed
+	default:
+		return fmt.Errorf("%s %s: unexpected status %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+	}
+}
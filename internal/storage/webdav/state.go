@@ -0,0 +1,81 @@
+package webdav
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stateFile is kept in a hidden directory of the local copy, so it isn't listed as a note.
+const stateFile = ".tg-notes/webdav.json"
+
+// state is an on-disk record of ETags of synced files and paths, which aren't uploaded yet.
+// It survives restarts, so changes aren't lost and conflicts are detected after restart.
+type state struct {
+	path string
+
+	ETags   map[string]string `json:"etags"`   // ETags of files on the server, when they were synced last time
+	Pending []string          `json:"pending"` // paths changed locally since the last upload
+}
+
+// loadState reads the state of the local copy in the directory.
+func loadState(dir string) (*state, error) {
+	const op = "storage.webdav.loadState"
+
+	s := &state{path: filepath.Join(dir, stateFile), ETags: make(map[string]string)}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: invalid state file %s: %w", op, s.path, err)
+	}
+
+	if s.ETags == nil {
+		s.ETags = make(map[string]string)
+	}
+
+	return s, nil
+}
+
+// save atomically replaces the state file and flushes it to disk.
+func (s *state) save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,572 @@
+// Package webdav provides storage of notes on a WebDAV server, e.g. Nextcloud.
+// Notes are saved to a local copy of the server directory right away and uploaded
+// by the Processor in batches. Files changed on the server in the meantime are detected
+// by ETags, local changes of such files are uploaded next to them.
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/fs"
+
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/osfs"
+)
+
+// Storage represents a WebDAV-backed storage for notes.
+type Storage struct {
+	*fs.Storage
+
+	client *client
+	local  billy.Filesystem
+	config *config.WebDAVConfig
+
+	mu       sync.Mutex
+	uploadMu sync.Mutex // serializes uploads, since Sync may be called while the Processor runs
+
+	state     *state // synced ETags and pending paths, guarded by mu
+	bufFullCh chan struct{}
+
+	onRemoteChange storage.RemoteChangeHandler // changes made on the server aren't reported, if nil
+}
+
+// New creates a new Storage. It downloads files changed on the server to the local copy,
+// except the ones changed locally and not uploaded before restart.
+func New(cfg *config.WebDAVConfig, collisionPolicy string) (*Storage, error) {
+	const op = "storage.webdav.New"
+
+	client, err := newClient(cfg.URL, cfg.User, cfg.Password, &http.Client{Timeout: cfg.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	state, err := loadState(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &Storage{
+		client:    client,
+		local:     osfs.New(cfg.Path),
+		config:    cfg,
+		state:     state,
+		bufFullCh: make(chan struct{}, 1),
+	}
+
+	s.Storage = fs.NewFS(s.local, collisionPolicy, s.track)
+
+	if _, err := s.download(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// changes, which weren't uploaded before restart, are uploaded first
+	if len(state.Pending) > 0 {
+		s.bufFullCh <- struct{}{}
+	}
+
+	return s, nil
+}
+
+// track records changed paths to upload them later.
+func (s *Storage) track(paths ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Pending = append(s.state.Pending, paths...)
+
+	if len(s.state.Pending) >= s.config.BufSize {
+		select {
+		case s.bufFullCh <- struct{}{}:
+		default:
+		}
+	}
+
+	if err := s.state.save(); err != nil {
+		return fmt.Errorf("state save error: %w", err)
+	}
+
+	return nil
+}
+
+// SetRemoteChangeHandler sets the handler, which is called when files changed on the server
+// are downloaded to the local copy. It must be called before the Processor is started.
+func (s *Storage) SetRemoteChangeHandler(handler storage.RemoteChangeHandler) {
+	s.onRemoteChange = handler
+}
+
+// Pending returns count of local changes, which aren't uploaded yet.
+func (s *Storage) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.state.Pending)
+}
+
+// Sync uploads pending changes right away. Conflicting changes are saved aside like by the Processor,
+// *storage.ConflictError with paths of the files, which they are saved to, is returned then.
+func (s *Storage) Sync(ctx context.Context) error {
+	const op = "storage.webdav.Sync"
+
+	_, conflicts, err := s.upload(ctx)
+
+	// conflicts saved aside before the failed upload are reported too
+	if len(conflicts) > 0 {
+		err = &storage.ConflictError{Locations: conflicts, Err: err}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Processor starts a background goroutine that periodically uploads changed files
+// to the server and downloads files changed on the server. onConflict is called with the path
+// of the file, which local changes were saved to, when the original file was changed on the server too.
+func (s *Storage) Processor(ctx context.Context, logger *slog.Logger, onConflict storage.ConflictHandler) {
+	const op = "storage.webdav.Processor"
+	logger = logger.With(log.Op(op))
+
+	duration := s.config.UpdateDuration
+
+	logger.Info("starting update storage", slog.String("duration", duration.String()))
+	ticker := time.Tick(duration)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-s.bufFullCh:
+			logger.Debug("start uploading changes, reason: buffer is full")
+			s.triggerUpdate(ctx, logger, onConflict)
+
+		case <-ticker:
+			logger.Debug("start uploading changes, reason: timer")
+			s.triggerUpdate(ctx, logger, onConflict)
+		}
+	}
+}
+
+// triggerUpdate uploads pending changes and then downloads changes made on the server,
+// so they reach the local copy without restart.
+func (s *Storage) triggerUpdate(ctx context.Context, logger *slog.Logger, onConflict storage.ConflictHandler) {
+	uploaded, conflicts, err := s.upload(ctx)
+
+	for _, conflict := range conflicts {
+		logger.Warn("file was changed on the server, local changes are saved aside", slog.String("path", conflict))
+		onConflict(ctx, conflict)
+	}
+
+	// local copies of conflicting files are replaced with the server version
+	changed := len(conflicts) > 0
+
+	switch {
+	case err != nil:
+		logger.Error("error while uploading changes, changes are kept until the next update",
+			log.Err(err), slog.Int("pending", s.Pending()))
+	case uploaded == 0:
+		logger.Debug("no new changes to upload")
+	default:
+		logger.Info("changes uploaded successfully", slog.Int("count", uploaded))
+	}
+
+	// the server is likely unavailable, if the upload failed
+	if err == nil {
+		downloaded, err := s.pull(ctx)
+		if err != nil {
+			logger.Error("error while downloading changes made on the server", log.Err(err))
+		} else if downloaded > 0 {
+			logger.Info("changes made on the server downloaded", slog.Int("count", downloaded))
+			changed = true
+		}
+	}
+
+	if changed && s.onRemoteChange != nil {
+		s.onRemoteChange(ctx)
+	}
+}
+
+// pull downloads changes made on the server like download and returns count of changed local files.
+// It waits for the upload, if any.
+func (s *Storage) pull(ctx context.Context) (int, error) {
+	const op = "storage.webdav.pull"
+
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+
+	changed, err := s.download(ctx)
+	if err != nil {
+		return changed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return changed, nil
+}
+
+// upload uploads pending changes and returns count of uploaded files and paths of files,
+// which conflicting changes were saved to. If upload fails, the failed file and the ones
+// after it are kept pending, state of uploaded files is saved.
+func (s *Storage) upload(ctx context.Context) (int, []string, error) {
+	const op = "storage.webdav.upload"
+
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+
+	s.mu.Lock()
+	pending := slices.Clone(s.state.Pending)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return 0, nil, nil
+	}
+
+	var (
+		conflicts []string
+		uploaded  = make(map[string]struct{}, len(pending))
+	)
+
+	for i, name := range pending {
+		if _, ok := uploaded[name]; ok {
+			continue
+		}
+
+		conflict, err := s.uploadFile(ctx, name)
+		if err != nil {
+			// files uploaded before the failed one aren't pending anymore,
+			// their ETags are saved, so they aren't taken for conflicts after restart
+			if saveErr := s.keepPending(len(pending), pending[i:]); saveErr != nil {
+				err = errors.Join(err, fmt.Errorf("state save error: %w", saveErr))
+			}
+
+			return len(uploaded), conflicts, fmt.Errorf("%s: %s: %w", op, name, err)
+		}
+
+		if conflict != "" {
+			conflicts = append(conflicts, conflict)
+		}
+
+		uploaded[name] = struct{}{}
+	}
+
+	if err := s.keepPending(len(pending), nil); err != nil {
+		return len(uploaded), conflicts, fmt.Errorf("%s: state save error: %w", op, err)
+	}
+
+	return len(uploaded), conflicts, nil
+}
+
+// keepPending replaces the first taken pending paths, which were handled by the upload,
+// with the ones left, and saves the state. Paths changed during the upload are kept for the next one.
+func (s *Storage) keepPending(taken int, left []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Pending = slices.Concat(left, s.state.Pending[taken:])
+
+	return s.state.save()
+}
+
+// uploadFile uploads the local file or deletes it on the server, if it was removed locally.
+// If the file was changed on the server since the last sync, local changes are uploaded
+// to a conflict file, which path is returned, and the server version is downloaded.
+// The storage lock is held, so the file isn't changed by saved notes meanwhile.
+func (s *Storage) uploadFile(ctx context.Context, name string) (string, error) {
+	var conflict string
+
+	err := s.Storage.WithLock(func() error {
+		var err error
+		conflict, err = s.uploadLocked(ctx, name)
+		return err
+	})
+
+	return conflict, err
+}
+
+// uploadLocked uploads the file like uploadFile. Caller must hold the storage lock.
+func (s *Storage) uploadLocked(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	synced := s.state.ETags[name]
+	s.mu.Unlock()
+
+	current, err := s.client.etag(ctx, name)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return "", err
+	}
+
+	data, err := s.readLocal(name)
+	if errors.Is(err, os.ErrNotExist) {
+		if current == "" {
+			s.setETag(name, "")
+			return "", nil
+		}
+
+		// removed locally, but changed on the server, so the server version is kept
+		if current != synced {
+			return name, s.downloadLocked(ctx, name)
+		}
+
+		if err := s.client.delete(ctx, name, current); errors.Is(err, errPreconditionFailed) {
+			return name, s.downloadLocked(ctx, name)
+		} else if err != nil {
+			return "", err
+		}
+
+		s.setETag(name, "")
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the file was changed on the server since the last sync, if it was removed there,
+	// it's just created again
+	if current != "" && current != synced {
+		return s.saveConflict(ctx, name, data)
+	}
+
+	if err := s.client.mkdirAll(ctx, path.Dir(name)); err != nil {
+		return "", err
+	}
+
+	etag, err := s.client.put(ctx, name, data, current)
+	if errors.Is(err, errPreconditionFailed) {
+		return s.saveConflict(ctx, name, data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// not every server returns ETag of uploaded file
+	if etag == "" {
+		if etag, err = s.client.etag(ctx, name); err != nil {
+			return "", err
+		}
+	}
+
+	s.setETag(name, etag)
+	return "", nil
+}
+
+// saveConflict uploads local changes to a new file next to the original one,
+// e.g. go/note.conflict-20250301-103000.md, and downloads the server version
+// of the original file. Returns path of the new file. Caller must hold the storage lock.
+func (s *Storage) saveConflict(ctx context.Context, name string, data []byte) (string, error) {
+	ext := path.Ext(name)
+	conflict := fmt.Sprintf("%s.conflict-%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102-150405"), ext)
+
+	if _, err := s.client.put(ctx, conflict, data, ""); err != nil {
+		return "", fmt.Errorf("conflict file upload error: %w", err)
+	}
+
+	if err := s.downloadLocked(ctx, name); err != nil {
+		return "", err
+	}
+
+	return conflict, nil
+}
+
+// download downloads files, which were changed on the server since the last sync,
+// and removes local copies of files removed on the server. Pending files are skipped.
+// Returns count of changed local files.
+func (s *Storage) download(ctx context.Context) (int, error) {
+	remote := make(map[string]string)
+	if err := s.remoteFiles(ctx, "", remote); err != nil {
+		return 0, fmt.Errorf("list files error: %w", err)
+	}
+
+	s.mu.Lock()
+	synced := make(map[string]string, len(s.state.ETags))
+	for name, etag := range s.state.ETags {
+		synced[name] = etag
+	}
+	s.mu.Unlock()
+
+	var changed int
+
+	for name, etag := range remote {
+		if synced[name] == etag {
+			continue
+		}
+
+		downloaded, err := s.downloadFile(ctx, name)
+		if err != nil {
+			return changed, err
+		}
+
+		if downloaded {
+			changed++
+		}
+	}
+
+	for name := range synced {
+		if _, ok := remote[name]; ok {
+			continue
+		}
+
+		removed, err := s.removeFile(name)
+		if err != nil {
+			return changed, fmt.Errorf("remove file %s error: %w", name, err)
+		}
+
+		if removed {
+			changed++
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.state.save(); err != nil {
+		return changed, fmt.Errorf("state save error: %w", err)
+	}
+
+	return changed, nil
+}
+
+// remoteFiles collects ETags of files in the server directory recursively.
+// Hidden files and directories, e.g. .obsidian, are skipped.
+func (s *Storage) remoteFiles(ctx context.Context, dir string, files map[string]string) error {
+	name := dir
+	if name != "" {
+		name += "/"
+	}
+
+	resources, err := s.client.list(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range resources {
+		if res.path == dir || strings.HasPrefix(path.Base(res.path), ".") {
+			continue
+		}
+
+		if res.dir {
+			if err := s.remoteFiles(ctx, res.path, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		files[res.path] = res.etag
+	}
+
+	return nil
+}
+
+// downloadFile writes the server version of the file to the local copy, unless the file
+// is changed locally and isn't uploaded yet. Reports whether the file was written.
+// The storage lock is held, so saved notes don't change the file meanwhile.
+func (s *Storage) downloadFile(ctx context.Context, name string) (bool, error) {
+	var downloaded bool
+
+	err := s.Storage.WithLock(func() error {
+		if s.pending(name) {
+			return nil
+		}
+
+		downloaded = true
+		return s.downloadLocked(ctx, name)
+	})
+
+	return downloaded, err
+}
+
+// removeFile removes the local copy of the file removed on the server, unless the file
+// is changed locally and isn't uploaded yet. Reports whether the file was removed.
+// The storage lock is held, so saved notes don't change the file meanwhile.
+func (s *Storage) removeFile(name string) (bool, error) {
+	var removed bool
+
+	err := s.Storage.WithLock(func() error {
+		if s.pending(name) {
+			return nil
+		}
+
+		err := s.local.Remove(name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		removed = err == nil
+		s.setETag(name, "")
+		return nil
+	})
+
+	return removed, err
+}
+
+// pending reports whether the file is changed locally and isn't uploaded yet.
+func (s *Storage) pending(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Contains(s.state.Pending, name)
+}
+
+// downloadLocked downloads the file like downloadFile. Caller must hold the storage lock.
+func (s *Storage) downloadLocked(ctx context.Context, name string) error {
+	data, etag, err := s.client.get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("download file %s error: %w", name, err)
+	}
+
+	if err := s.local.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	file, err := s.local.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	s.setETag(name, etag)
+	return nil
+}
+
+func (s *Storage) readLocal(name string) ([]byte, error) {
+	file, err := s.local.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// setETag records ETag of the synced file, empty ETag removes the record.
+func (s *Storage) setETag(name, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if etag == "" {
+		delete(s.state.ETags, name)
+		return
+	}
+
+	s.state.ETags[name] = etag
+}
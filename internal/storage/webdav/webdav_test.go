@@ -0,0 +1,269 @@
+package webdav_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/log"
+	tgstorage "protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/webdav"
+
+	"github.com/stretchr/testify/require"
+	xwebdav "golang.org/x/net/webdav"
+)
+
+const (
+	user     = "user"
+	password = "secret"
+)
+
+// newServer starts an in-process WebDAV server with basic auth and returns
+// its URL and the directory it serves.
+func newServer(t *testing.T) (string, string) {
+	t.Helper()
+
+	return newFailingServer(t, func(*http.Request) bool { return false })
+}
+
+// newFailingServer starts a server like newServer, which responds with an error to requests, which fail.
+func newFailingServer(t *testing.T, fail func(*http.Request) bool) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	handler := &xwebdav.Handler{FileSystem: xwebdav.Dir(dir), LockSystem: xwebdav.NewMemLS()}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if fail(r) {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL + "/notes", dir
+}
+
+func newConfig(t *testing.T, url string) *config.WebDAVConfig {
+	t.Helper()
+
+	return &config.WebDAVConfig{
+		URL:            url,
+		User:           user,
+		Password:       password,
+		Path:           filepath.Join(t.TempDir(), "notes"),
+		Timeout:        5 * time.Second,
+		BufSize:        10,
+		UpdateDuration: time.Hour,
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func TestNewDownloadsNotes(t *testing.T) {
+	url, dir := newServer(t)
+	writeFile(t, filepath.Join(dir, "notes", "go", "remote.md"), "---\ntitle: Remote\n---\ntext")
+	writeFile(t, filepath.Join(dir, "notes", ".obsidian", "app.json"), "{}")
+
+	storage, err := webdav.New(newConfig(t, url), config.CollisionSuffix)
+	require.NoError(t, err)
+
+	notes, err := storage.Notes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Remote", notes[0].Title)
+	require.Equal(t, domain.Category("go"), notes[0].Category)
+}
+
+func TestNewRequiresAuth(t *testing.T) {
+	url, _ := newServer(t)
+
+	cfg := newConfig(t, url)
+	cfg.Password = "wrong"
+
+	_, err := webdav.New(cfg, config.CollisionSuffix)
+	require.ErrorContains(t, err, "401")
+}
+
+func TestSyncUploadsChanges(t *testing.T) {
+	url, dir := newServer(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "notes"), 0o755))
+
+	storage, err := webdav.New(newConfig(t, url), config.CollisionSuffix)
+	require.NoError(t, err)
+
+	note := domain.Note{
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+	require.Equal(t, 2, storage.Pending())
+
+	require.NoError(t, storage.Sync(context.Background()))
+	require.Zero(t, storage.Pending())
+
+	require.Contains(t, readFile(t, filepath.Join(dir, "notes", "go", "note.md")), "text")
+	require.Equal(t, "png", readFile(t, filepath.Join(dir, "notes", "go", domain.AssetPath("note", "image.png"))))
+
+	require.NoError(t, storage.Delete(context.Background(), "go", "note"))
+	require.NoError(t, storage.Sync(context.Background()))
+
+	require.NoFileExists(t, filepath.Join(dir, "notes", "go", "note.md"))
+}
+
+func TestSyncConflictSavesChangesAside(t *testing.T) {
+	url, dir := newServer(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "notes"), 0o755))
+
+	storage, err := webdav.New(newConfig(t, url), config.CollisionAppend)
+	require.NoError(t, err)
+
+	note := domain.Note{Name: "note", Category: "go", Content: "text"}
+
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+	require.NoError(t, storage.Sync(context.Background()))
+
+	remotePath := filepath.Join(dir, "notes", "go", "note.md")
+	writeFile(t, remotePath, "edited elsewhere")
+
+	note.Content = "appended"
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	var conflict *tgstorage.ConflictError
+	require.ErrorAs(t, storage.Sync(context.Background()), &conflict)
+	require.NoError(t, conflict.Err)
+
+	require.Equal(t, "edited elsewhere", readFile(t, remotePath))
+
+	conflicts, err := filepath.Glob(filepath.Join(dir, "notes", "go", "note.conflict-*.md"))
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "text\n\nappended", readFile(t, conflicts[0]))
+
+	// the conflict file is reported to the user
+	location, err := filepath.Rel(filepath.Join(dir, "notes"), conflicts[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{location}, conflict.Locations)
+
+	// the local copy is replaced with the server version
+	stored, err := storage.Note(context.Background(), "go", "note")
+	require.NoError(t, err)
+	require.Equal(t, "edited elsewhere", stored.Content)
+}
+
+func TestSyncFailureKeepsUploadedFilesSynced(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+
+	url, dir := newFailingServer(t, func(r *http.Request) bool {
+		return failing.Load() && r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, ".png")
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "notes"), 0o755))
+
+	cfg := newConfig(t, url)
+
+	storage, err := webdav.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	note := domain.Note{
+		Name:        "note",
+		Category:    "go",
+		Content:     "text",
+		Attachments: []domain.Attachment{{Name: "image.png", Data: []byte("png")}},
+	}
+
+	_, _, err = storage.Add(context.Background(), note)
+	require.NoError(t, err)
+
+	require.Error(t, storage.Sync(context.Background()))
+	require.Equal(t, 1, storage.Pending())
+	require.Contains(t, readFile(t, filepath.Join(dir, "notes", "go", "note.md")), "text")
+
+	// the uploaded note is known as synced after restart, so it isn't taken for a conflict
+	restarted, err := webdav.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+	require.Equal(t, 1, restarted.Pending())
+
+	failing.Store(false)
+	require.NoError(t, restarted.Sync(context.Background()))
+	require.Zero(t, restarted.Pending())
+
+	conflicts, err := filepath.Glob(filepath.Join(dir, "notes", "go", "*.conflict-*"))
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	require.Equal(t, "png", readFile(t, filepath.Join(dir, "notes", "go", domain.AssetPath("note", "image.png"))))
+}
+
+func TestProcessorDownloadsRemoteChanges(t *testing.T) {
+	url, dir := newServer(t)
+	writeFile(t, filepath.Join(dir, "notes", "go", "removed.md"), "removed")
+
+	cfg := newConfig(t, url)
+	cfg.UpdateDuration = 50 * time.Millisecond
+
+	storage, err := webdav.New(cfg, config.CollisionSuffix)
+	require.NoError(t, err)
+
+	changes := make(chan struct{}, 10)
+	storage.SetRemoteChangeHandler(func(context.Context) {
+		changes <- struct{}{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// files are changed on the server after the start
+	writeFile(t, filepath.Join(dir, "notes", "go", "remote.md"), "remote")
+	require.NoError(t, os.Remove(filepath.Join(dir, "notes", "go", "removed.md")))
+
+	go storage.Processor(ctx, slog.New(log.NewDiscardHandler()), func(context.Context, string) {})
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "remote changes weren't reported")
+	}
+
+	note, err := storage.Note(context.Background(), "go", "remote")
+	require.NoError(t, err)
+	require.Equal(t, "remote", note.Content)
+
+	_, err = storage.Note(context.Background(), "go", "removed")
+	require.ErrorIs(t, err, domain.ErrNoteNotFound)
+}
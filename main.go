@@ -6,23 +6,39 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
+	"protomorphine/tg-notes/internal/api"
 	"protomorphine/tg-notes/internal/app/nlp"
-	usecase "protomorphine/tg-notes/internal/app/usecases/notesaving"
+	"protomorphine/tg-notes/internal/app/users"
 	handler "protomorphine/tg-notes/internal/bot/handlers/notesaving"
+	searchhandler "protomorphine/tg-notes/internal/bot/handlers/search"
+	"protomorphine/tg-notes/internal/bot/webhook"
 	"protomorphine/tg-notes/internal/config"
 	"protomorphine/tg-notes/internal/log"
-	"protomorphine/tg-notes/internal/storage/git"
+	"protomorphine/tg-notes/internal/metrics"
+
+	"github.com/go-telegram/bot"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// pendingNotesLimit is a count of the last saved notes, which category can be changed.
+const pendingNotesLimit = 100
+
 type CLIArgs struct {
 	configPath string
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == evalCmd {
+		os.Exit(runEval(os.Args[2:]))
+	}
+
 	args, err := parseAndValidateCLIArgs()
 	if err != nil {
 		slog.Error("error while parsing CLI args", log.Err(err))
@@ -35,22 +51,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := cfg.Bot.Validate(); err != nil {
+		slog.Error("invalid bot config", log.Err(err))
+		os.Exit(1)
+	}
+
 	logger := configureLogger(cfg.Environment, &cfg.Logger)
 	logger = logger.With(slog.String("env", cfg.Environment))
 
 	logger.Info("starting tg-notes app")
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	// SIGTERM is sent by Docker on stop, SIGKILL can't be caught
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	storage, err := git.New(&cfg.GitRepository)
-	if err != nil {
-		logger.Error("error while setting up storage", log.Err(err))
-		os.Exit(1)
-	}
+	shutdownCtx, cancelShutdown := withShutdownTimeout(ctx, cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	metricsRegistry := prometheus.NewRegistry()
+	appMetrics := metrics.New(metricsRegistry)
 
-	go storage.Processor(ctx, logger)
-	logger.Info("successfully initialized git storage")
+	// the server is started first, so health checks pass while users are set up
+	ready := newReadiness(checkUsers, checkUpdates)
+	router := newRouter(ready, metricsRegistry)
+	server := startServer(logger, cfg.HTTPServer.Addr, router)
 
 	nlpProcessor, err := nlp.NewProcessor()
 	if err != nil {
@@ -58,16 +82,28 @@ func main() {
 		os.Exit(1)
 	}
 
-	notes, err := storage.Notes(ctx)
+	registry, storages, err := newUsersRegistry(ctx, logger, cfg, nlpProcessor, appMetrics)
 	if err != nil {
-		logger.Error("error while getting notes for training", log.Err(err))
+		logger.Error("error while setting up users", log.Err(err))
 		os.Exit(1)
 	}
 
-	classifier := nlp.NewClassifier(nlpProcessor, notes)
-	usecase := usecase.New(storage, classifier, &cfg.NoteSave)
+	observeStorages(logger, appMetrics, storages)
+	mountAPI(logger, router, cfg, registry)
+	ready.set(checkUsers, true)
 
-	b, err := newBot(logger, &cfg.Bot, handler.New(logger, usecase))
+	pendingNotes := handler.NewPendingNotes(pendingNotesLimit)
+
+	b, err := newBot(
+		shutdownCtx,
+		logger,
+		&cfg.Bot,
+		registry,
+		handler.New(logger, registry, pendingNotes, &cfg.Attachments),
+		handler.NewCategoryCallback(logger, registry, pendingNotes),
+		searchhandler.New(logger, registry),
+		appMetrics,
+	)
 	if err != nil {
 		logger.Error("error while Telegram bot initialization", log.Err(err))
 		os.Exit(1)
@@ -75,16 +111,65 @@ func main() {
 
 	logger.Info("successfully authorized in telegram api")
 
-	removeWebhook, err := setWebhook(ctx, logger, b, cfg.Bot.WebHookURL)
+	processors := startStorageProcessors(ctx, storages, b)
+
+	switch cfg.Bot.Mode {
+	case config.BotModePolling:
+		err = startPolling(ctx, logger, b, ready)
+	default:
+		err = serveWebhook(ctx, shutdownCtx, logger, cfg, b, server, router, ready)
+	}
+
+	exitCode := 0
+
 	if err != nil {
-		logger.Error("error while setting up webhook", log.Err(err))
-		os.Exit(1)
+		logger.Error("error while receiving updates", log.Err(err))
+		exitCode = 1
+
+		// processors are stopped with ctx, so pending notes are flushed like on a signal
+		stop()
 	}
-	defer removeWebhook()
 
+	// the server is already stopped in webhook mode
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("error while HTTP server shutdown", log.Err(err))
+	}
+
+	// notes saved by the last handlers are flushed after processors stop,
+	// so they don't save the same notes concurrently
+	logger.Info("shutting down, saving pending notes", slog.String("timeout", cfg.ShutdownTimeout.String()))
+
+	processors.Wait()
+	flushStorages(shutdownCtx, storages, b)
+	saveClassifiers(storages)
+
+	logger.Info("tg-notes app stopped")
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// withShutdownTimeout returns context, which is done the timeout after ctx is done.
+// It bounds graceful shutdown, which starts when ctx is done.
+func withShutdownTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	shutdownCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(timeout, cancel)
+	})
+
+	return shutdownCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// startServer starts HTTP server in background. It's stopped with Shutdown.
+func startServer(logger *slog.Logger, addr string, handler http.Handler) *http.Server {
 	server := &http.Server{
-		Addr:    cfg.HTTPServer.Addr,
-		Handler: b.WebhookHandler(),
+		Addr:    addr,
+		Handler: handler,
 	}
 
 	go func() {
@@ -97,13 +182,83 @@ func main() {
 		logger.Info("http server stopped")
 	}()
 
-	go b.StartWebhook(ctx)
+	return server
+}
+
+// mountAPI serves HTTP API of notes, if any set up user has API token.
+func mountAPI(logger *slog.Logger, router *http.ServeMux, cfg *config.Config, registry *users.Registry) {
+	tokens := cfg.APITokens()
+	maps.DeleteFunc(tokens, func(_ string, id int64) bool {
+		return !registry.Has(id)
+	})
+
+	if len(tokens) == 0 {
+		return
+	}
+
+	router.Handle("/api/", api.New(logger, registry, tokens))
+	logger.Info("HTTP API enabled", slog.Int("users", len(tokens)))
+}
+
+// serveWebhook registers webhook and serves incoming updates with the router. Blocks until ctx is done,
+// then stops taking new updates and waits for handlers in progress until shutdownCtx is done.
+func serveWebhook(
+	ctx, shutdownCtx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	b *bot.Bot,
+	server *http.Server,
+	router *http.ServeMux,
+	ready *readiness,
+) error {
+	// the webhook is registered on every start, so a random secret works, unless it's shared by replicas
+	secret := cfg.Bot.WebhookSecret
+	if secret == "" {
+		secret = webhook.NewSecret()
+	}
+
+	networks, err := cfg.Bot.WebhookNetworks()
+	if err != nil {
+		return fmt.Errorf("error while setting up webhook: %w", err)
+	}
+
+	removeWebhook, err := setWebhook(ctx, logger, b, cfg.Bot.WebHookURL, secret)
+	if err != nil {
+		return fmt.Errorf("error while setting up webhook: %w", err)
+	}
+	defer removeWebhook()
+
+	router.Handle("/", webhook.NewGuard(logger, secret, networks, b.WebhookHandler()))
+
+	// workers are stopped after the server, since webhook requests in progress wait for them
+	workersCtx, stopWorkers := context.WithCancel(shutdownCtx)
+	defer stopWorkers()
+
+	workersDone := make(chan struct{})
+	go func() {
+		b.StartWebhook(workersCtx)
+		close(workersDone)
+	}()
+
+	ready.set(checkUpdates, true)
 
 	<-ctx.Done()
 
-	if err := server.Shutdown(context.Background()); err != nil {
+	ready.set(checkUpdates, false)
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Error("error while HTTP server shutdown", log.Err(err))
 	}
+
+	stopWorkers()
+
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		logger.Warn("handlers weren't finished before shutdown timeout")
+	}
+
+	return nil
 }
 
 func parseAndValidateCLIArgs() (*CLIArgs, error) {
@@ -111,14 +266,22 @@ func parseAndValidateCLIArgs() (*CLIArgs, error) {
 
 	flag.Parse()
 
-	// check if config path is not empty and file exists
-	if *configPath == "" {
-		return nil, errors.New("config path shouldn't be empty")
+	if err := validateConfigPath(*configPath); err != nil {
+		return nil, err
+	}
+
+	return &CLIArgs{configPath: *configPath}, nil
+}
+
+// validateConfigPath checks if config path is not empty and file exists.
+func validateConfigPath(configPath string) error {
+	if configPath == "" {
+		return errors.New("config path shouldn't be empty")
 	}
 
-	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file does not exists: %s", *configPath)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return fmt.Errorf("file does not exists: %s", configPath)
 	}
 
-	return &CLIArgs{configPath: *configPath}, nil
+	return nil
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithShutdownTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	shutdownCtx, cancel := withShutdownTimeout(ctx, timeout)
+	defer cancel()
+
+	// the timeout starts, when ctx is done
+	require.Never(t, func() bool { return shutdownCtx.Err() != nil }, 2*timeout, 10*time.Millisecond)
+
+	start := time.Now()
+	stop()
+
+	<-shutdownCtx.Done()
+	require.GreaterOrEqual(t, time.Since(start), timeout)
+}
+
+func TestWithShutdownTimeoutCancel(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	shutdownCtx, cancel := withShutdownTimeout(ctx, time.Hour)
+
+	// cancel releases the context before ctx is done
+	cancel()
+
+	require.ErrorIs(t, shutdownCtx.Err(), context.Canceled)
+	require.NoError(t, ctx.Err())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"protomorphine/tg-notes/internal/app/models"
+	"protomorphine/tg-notes/internal/app/usecases/notesaving"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/metrics"
+	"protomorphine/tg-notes/internal/storage/git"
+)
+
+// pendingStorage is a storage, which reports count of changes not saved to the remote yet.
+type pendingStorage interface {
+	Pending() int
+}
+
+// measuredSaver saves notes with the underlying saver and counts saved ones.
+type measuredSaver struct {
+	notesaving.NoteSaver
+	metrics *metrics.Metrics
+}
+
+func (s *measuredSaver) Save(ctx context.Context, input models.NoteInput) (models.SaveResult, error) {
+	result, err := s.NoteSaver.Save(ctx, input)
+	if err != nil {
+		return models.SaveResult{}, err
+	}
+
+	s.metrics.NoteSaved(result.Outcome, result.Fallback)
+	return result, nil
+}
+
+// observeStorages exports metrics of users' storages, which provide them.
+func observeStorages(logger *slog.Logger, m *metrics.Metrics, storages []userStorage) {
+	for _, s := range storages {
+		if gitStorage, ok := s.storage.(*git.GitStorage); ok {
+			gitStorage.SetObserver(m)
+		}
+
+		if pending, ok := s.storage.(pendingStorage); ok {
+			if err := m.RegisterPending(s.userID, pending.Pending); err != nil {
+				logger.Error("error while registering storage metrics", slog.Int64("userID", s.userID), log.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"protomorphine/tg-notes/internal/app/models"
+	ucmocks "protomorphine/tg-notes/internal/app/usecases/notesaving/mocks"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasuredSaver(t *testing.T) {
+	saver := ucmocks.NewNoteSaver(t)
+	saver.EXPECT().Save(mock.Anything, models.NoteInput{Text: "created"}).
+		Return(models.SaveResult{Outcome: domain.SaveCreated}, nil).Once()
+	saver.EXPECT().Save(mock.Anything, models.NoteInput{Text: "fallback"}).
+		Return(models.SaveResult{Outcome: domain.SaveCreated, Fallback: true}, nil).Once()
+	saver.EXPECT().Save(mock.Anything, models.NoteInput{Text: "appended"}).
+		Return(models.SaveResult{Outcome: domain.SaveAppended}, nil).Once()
+	saver.EXPECT().Save(mock.Anything, models.NoteInput{Text: "failed"}).
+		Return(models.SaveResult{}, errors.New("disk is full")).Once()
+
+	registry := prometheus.NewRegistry()
+	measured := &measuredSaver{NoteSaver: saver, metrics: metrics.New(registry)}
+
+	for _, text := range []string{"created", "fallback", "appended"} {
+		_, err := measured.Save(context.Background(), models.NoteInput{Text: text})
+		require.NoError(t, err)
+	}
+
+	// failed saves aren't counted
+	_, err := measured.Save(context.Background(), models.NoteInput{Text: "failed"})
+	require.Error(t, err)
+
+	expected := `
+# HELP tgnotes_category_fallbacks_total Count of notes saved to the default category, since the predicted one wasn't confident enough.
+# TYPE tgnotes_category_fallbacks_total counter
+tgnotes_category_fallbacks_total 1
+# HELP tgnotes_notes_saved_total Count of saved notes by save outcome.
+# TYPE tgnotes_notes_saved_total counter
+tgnotes_notes_saved_total{outcome="appended"} 1
+tgnotes_notes_saved_total{outcome="created"} 2
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"tgnotes_category_fallbacks_total",
+		"tgnotes_notes_saved_total",
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Readiness checks, which must pass before the app takes updates.
+const (
+	checkUsers   = "users"   // users' storages are set up and classifiers are trained
+	checkUpdates = "updates" // webhook is registered or long polling is started
+)
+
+// readiness tracks checks, which the app must pass to be ready.
+type readiness struct {
+	mu     sync.Mutex
+	order  []string // names of checks in the order of registration
+	checks map[string]bool
+}
+
+func newReadiness(checks ...string) *readiness {
+	r := &readiness{
+		order:  slices.Clone(checks),
+		checks: make(map[string]bool, len(checks)),
+	}
+
+	for _, check := range checks {
+		r.checks[check] = false
+	}
+
+	return r
+}
+
+// set marks the check as passed or failed.
+func (r *readiness) set(check string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[check] = ok
+}
+
+// failed returns names of checks, which aren't passed, in the order of registration.
+func (r *readiness) failed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var failed []string
+	for _, check := range r.order {
+		if !r.checks[check] {
+			failed = append(failed, check)
+		}
+	}
+
+	return failed
+}
+
+// newRouter creates router with health, readiness and metrics endpoints. Telegram updates
+// are served at the root, once the webhook handler is mounted.
+func newRouter(ready *readiness, gatherer prometheus.Gatherer) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if failed := ready.failed(); len(failed) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "not ready: %s\n", strings.Join(failed, ", "))
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.Handle("GET /metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	return mux
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyz(t *testing.T) {
+	ready := newReadiness(checkUsers, checkUpdates)
+	router := newRouter(ready, prometheus.NewRegistry())
+
+	steps := []struct {
+		name           string
+		set            func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "no checks passed",
+			set:            func() {},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "not ready: users, updates\n",
+		},
+		{
+			name:           "users are set up",
+			set:            func() { ready.set(checkUsers, true) },
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "not ready: updates\n",
+		},
+		{
+			name:           "updates are taken",
+			set:            func() { ready.set(checkUpdates, true) },
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok\n",
+		},
+		{
+			name:           "updates are stopped on shutdown",
+			set:            func() { ready.set(checkUpdates, false) },
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "not ready: updates\n",
+		},
+	}
+
+	// steps depend on each other, so they run in order
+	for _, step := range steps {
+		step.set()
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		require.Equal(t, step.expectedStatus, rec.Code, step.name)
+		require.Equal(t, step.expectedBody, rec.Body.String(), step.name)
+	}
+}
+
+func TestReadinessFailedRegisteredChecks(t *testing.T) {
+	ready := newReadiness("storage", checkUsers)
+
+	require.Equal(t, []string{"storage", checkUsers}, ready.failed())
+
+	ready.set("storage", true)
+	require.Equal(t, []string{checkUsers}, ready.failed())
+
+	// checks, which aren't registered, don't affect readiness
+	ready.set(checkUsers, true)
+	ready.set(checkUpdates, false)
+	require.Empty(t, ready.failed())
+}
+
+func TestHealthz(t *testing.T) {
+	router := newRouter(newReadiness(checkUsers), prometheus.NewRegistry())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "ok\n", rec.Body.String())
+}
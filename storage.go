@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"protomorphine/tg-notes/internal/app/search"
+	"protomorphine/tg-notes/internal/domain"
+	"protomorphine/tg-notes/internal/storage"
+)
+
+// indexedStorage changes notes in the underlying storage and keeps search index up to date.
+type indexedStorage struct {
+	storage.Storage
+	index *search.Index
+}
+
+func (s *indexedStorage) Add(ctx context.Context, note domain.Note) (domain.Note, domain.SaveOutcome, error) {
+	note, outcome, err := s.Storage.Add(ctx, note)
+	if err != nil {
+		return domain.Note{}, "", err
+	}
+
+	s.index.Add(note)
+	return note, outcome, nil
+}
+
+func (s *indexedStorage) Move(ctx context.Context, name string, from, to domain.Category) (domain.Note, error) {
+	note, err := s.Storage.Move(ctx, name, from, to)
+	if err != nil {
+		return domain.Note{}, err
+	}
+
+	s.index.Move(name, from, to)
+	return note, nil
+}
+
+func (s *indexedStorage) Delete(ctx context.Context, category domain.Category, name string) error {
+	note, err := s.Storage.Note(ctx, category, name)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Storage.Delete(ctx, category, name); err != nil {
+		return err
+	}
+
+	s.index.Remove(note)
+	return nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+
+	"protomorphine/tg-notes/internal/app/nlp"
+	"protomorphine/tg-notes/internal/app/search"
+	"protomorphine/tg-notes/internal/app/usecases/notesaving"
+	"protomorphine/tg-notes/internal/app/usecases/recategorizing"
+	searchusecase "protomorphine/tg-notes/internal/app/usecases/search"
+	"protomorphine/tg-notes/internal/app/users"
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/metrics"
+	"protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/fs"
+	"protomorphine/tg-notes/internal/storage/git"
+	"protomorphine/tg-notes/internal/storage/webdav"
+
+	"github.com/go-telegram/bot"
+)
+
+// conflictTmpl is a message sent to the user, when notes conflict with remote changes and are saved aside.
+//
+//go:embed resources/conflict.tmpl
+var conflictTmpl string
+
+var conflictMsg = template.Must(template.New("conflict").Parse(conflictTmpl))
+
+// userStorage is a storage of the user, which may save changes in background.
+type userStorage struct {
+	userID     int64
+	logger     *slog.Logger
+	storage    storage.Storage
+	classifier *nlp.Classifier // trained on notes of the storage
+	modelPath  string          // classifier model isn't persisted, if empty
+}
+
+// backgroundStorage is a storage, which saves changes in background, e.g. to a remote repository.
+type backgroundStorage interface {
+	Processor(ctx context.Context, logger *slog.Logger, onConflict storage.ConflictHandler)
+}
+
+// remoteChangeReporter is a storage, which reports notes changed remotely, e.g. pulled from a repository.
+type remoteChangeReporter interface {
+	SetRemoteChangeHandler(handler storage.RemoteChangeHandler)
+}
+
+// newUsersRegistry sets up usecases of every configured user. A user whose storage
+// can't be set up is skipped, so one broken repository doesn't block other users.
+// Storages of the users are returned to start their background processors.
+func newUsersRegistry(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	processor *nlp.Processor,
+	m *metrics.Metrics,
+) (*users.Registry, []userStorage, error) {
+	registry := users.NewRegistry()
+
+	var storages []userStorage
+
+	for i := range cfg.Users {
+		userCfg := &cfg.Users[i]
+		userLogger := logger.With(slog.Int64("userID", userCfg.ID))
+
+		usecases, storage, err := newUserUsecases(ctx, userLogger, userCfg, &cfg.Search, processor)
+		if err != nil {
+			logger.Error("error while setting up user", slog.Int64("userID", userCfg.ID), log.Err(err))
+			continue
+		}
+
+		usecases.Saver = &measuredSaver{NoteSaver: usecases.Saver, metrics: m}
+
+		registry.Register(userCfg.ID, usecases)
+		storages = append(storages, storage)
+	}
+
+	if len(registry.IDs()) == 0 {
+		return nil, nil, fmt.Errorf("no users were set up")
+	}
+
+	return registry, storages, nil
+}
+
+// startStorageProcessors starts background processors of users' storages, which have them.
+// Users are notified via the bot, when their notes conflict with remote changes.
+// Returned WaitGroup is done, when processors are stopped after ctx is done.
+func startStorageProcessors(ctx context.Context, storages []userStorage, b *bot.Bot) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	for _, s := range storages {
+		if processor, ok := s.storage.(backgroundStorage); ok {
+			wg.Go(func() {
+				processor.Processor(ctx, s.logger, newConflictNotifier(s.logger, b, s.userID))
+			})
+		}
+	}
+
+	return &wg
+}
+
+// flushStorages saves pending changes of users' storages, e.g. on shutdown.
+// Storages are flushed concurrently until ctx is done. Users are notified via the bot,
+// when their notes conflict with remote changes.
+func flushStorages(ctx context.Context, storages []userStorage, b *bot.Bot) {
+	var wg sync.WaitGroup
+
+	for _, s := range storages {
+		wg.Go(func() {
+			err := s.storage.Sync(ctx)
+
+			var conflict *storage.ConflictError
+			if errors.As(err, &conflict) {
+				s.logger.Warn("pending changes conflict with remote changes, saved aside",
+					slog.Any("locations", conflict.Locations))
+
+				notify := newConflictNotifier(s.logger, b, s.userID)
+				for _, location := range conflict.Locations {
+					notify(ctx, location)
+				}
+
+				err = conflict.Err
+			}
+
+			if err != nil {
+				s.logger.Error("error while saving pending changes, they are saved after restart", log.Err(err))
+				return
+			}
+
+			s.logger.Info("pending changes saved")
+		})
+	}
+
+	wg.Wait()
+}
+
+// newConflictNotifier returns a handler, which tells the user where conflicting notes were saved.
+func newConflictNotifier(logger *slog.Logger, b *bot.Bot, userID int64) storage.ConflictHandler {
+	return func(ctx context.Context, location string) {
+		var text strings.Builder
+		if err := conflictMsg.Execute(&text, struct{ Location string }{Location: location}); err != nil {
+			logger.Error("error while rendering conflict notification", log.Err(err))
+			return
+		}
+
+		// chat with the bot has the same ID as the user
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{ChatID: userID, Text: text.String()}); err != nil {
+			logger.Error("error while sending conflict notification", log.Err(err))
+		}
+	}
+}
+
+// newRemoteChangeHandler returns a handler, which rebuilds search index and retrains classifier
+// on stored notes, since notes pulled from remote or moved aside aren't known to them.
+func newRemoteChangeHandler(
+	logger *slog.Logger,
+	s storage.Storage,
+	index *search.Index,
+	classifier *nlp.Classifier,
+) storage.RemoteChangeHandler {
+	return func(ctx context.Context) {
+		notes, err := s.Notes(ctx)
+		if err != nil {
+			logger.Error("error while getting notes changed remotely, search and classifier may be outdated", log.Err(err))
+			return
+		}
+
+		index.Rebuild(notes)
+		classifier.Retrain(notes)
+
+		logger.Info("search index and classifier are rebuilt after remote changes", slog.Int("notes", len(notes)))
+	}
+}
+
+// newUserUsecases sets up storage, classifier and search index of the user.
+func newUserUsecases(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.UserConfig,
+	searchCfg *config.SearchConfig,
+	processor *nlp.Processor,
+) (*users.Usecases, userStorage, error) {
+	storage, err := newStorage(cfg)
+	if err != nil {
+		return nil, userStorage{}, fmt.Errorf("error while setting up storage: %w", err)
+	}
+
+	logger.Info("successfully initialized storage", slog.String("type", cfg.Storage.Type))
+
+	notes, err := storage.Notes(ctx)
+	if err != nil {
+		// the user is skipped, so the storage isn't used anymore
+		closeStorage(logger, storage)
+		return nil, userStorage{}, fmt.Errorf("error while getting notes for training: %w", err)
+	}
+
+	classifier := newClassifier(logger, &cfg.Classifier, storage, processor, notes)
+	index := search.NewIndex(processor, notes)
+
+	indexedStorage := &indexedStorage{Storage: storage, index: index}
+
+	if reporter, ok := storage.(remoteChangeReporter); ok {
+		reporter.SetRemoteChangeHandler(newRemoteChangeHandler(logger, storage, index, classifier))
+	}
+
+	return &users.Usecases{
+		Saver:         notesaving.New(indexedStorage, classifier, processor, &cfg.NoteSave),
+		Recategorizer: recategorizing.New(indexedStorage, indexedStorage, classifier),
+		Searcher:      searchusecase.New(index, searchCfg),
+		Storage:       indexedStorage,
+	}, userStorage{
+		userID:     cfg.ID,
+		logger:     logger,
+		storage:    storage,
+		classifier: classifier,
+		modelPath:  cfg.Classifier.ModelPath,
+	}, nil
+}
+
+// closeStorage releases resources of the storage, which has them, e.g. open files.
+// Background processors of storages are stopped with their context instead.
+func closeStorage(logger *slog.Logger, s storage.Storage) {
+	closer, ok := s.(io.Closer)
+	if !ok {
+		return
+	}
+
+	if err := closer.Close(); err != nil {
+		logger.Error("error while closing storage", log.Err(err))
+	}
+}
+
+// newStorage sets up the notes storage of the configured type.
+func newStorage(cfg *config.UserConfig) (storage.Storage, error) {
+	switch cfg.Storage.Type {
+	case config.StorageFS:
+		return fs.New(&cfg.Storage)
+	case config.StorageWebDAV:
+		return webdav.New(&cfg.WebDAV, cfg.Storage.CollisionPolicy)
+	default:
+		return git.New(&cfg.GitRepository, cfg.Storage.CollisionPolicy)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"protomorphine/tg-notes/internal/config"
+	"protomorphine/tg-notes/internal/log"
+	"protomorphine/tg-notes/internal/storage"
+	"protomorphine/tg-notes/internal/storage/fs"
+
+	"github.com/go-telegram/bot"
+	"github.com/stretchr/testify/require"
+)
+
+// syncedStorage is a storage, which Sync returns the error.
+type syncedStorage struct {
+	storage.Storage
+	err error
+}
+
+func (s syncedStorage) Sync(context.Context) error {
+	return s.err
+}
+
+// newTestBot returns the bot, which sends requests to the test server, and channel
+// of chat IDs, which messages are sent to.
+func newTestBot(t *testing.T) (*bot.Bot, <-chan string) {
+	t.Helper()
+
+	chats := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		chats <- r.FormValue("chat_id")
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1,"type":"private"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	b, err := bot.New("token", bot.WithServerURL(server.URL), bot.WithSkipGetMe())
+	require.NoError(t, err)
+
+	return b, chats
+}
+
+func TestFlushStoragesNotifiesConflicts(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		expectedNotified int
+	}{
+		{
+			name: "saved",
+		},
+		{
+			name:             "conflict",
+			err:              &storage.ConflictError{Locations: []string{"main-conflict-20250301-103000"}},
+			expectedNotified: 1,
+		},
+		{
+			name: "conflicts and error",
+			err: &storage.ConflictError{
+				Locations: []string{"go/a.conflict-20250301-103000.md", "go/b.conflict-20250301-103000.md"},
+				Err:       errors.New("server is unavailable"),
+			},
+			expectedNotified: 2,
+		},
+		{
+			name: "error",
+			err:  errors.New("remote is unavailable"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, chats := newTestBot(t)
+
+			notes, err := fs.New(&config.StorageConfig{Path: t.TempDir(), CollisionPolicy: config.CollisionSuffix})
+			require.NoError(t, err)
+
+			flushStorages(context.Background(), []userStorage{{
+				userID:  42,
+				logger:  slog.New(log.NewDiscardHandler()),
+				storage: syncedStorage{Storage: notes, err: tc.err},
+			}}, b)
+
+			require.Len(t, chats, tc.expectedNotified)
+
+			for range tc.expectedNotified {
+				require.Equal(t, "42", <-chats)
+			}
+		})
+	}
+}